@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func runClone(args []string) error {
+	fs := flag.NewFlagSet("clone", flag.ExitOnError)
+	name := fs.String("name", "", "project name (default: repository name)")
+	path := fs.String("path", "", "where to clone (default: clone_root and clone_layout settings)")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("usage: asap-pm clone <url> [--name <name>] [--path <path>]")
+	}
+	remote := args[0]
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	settings, err := project.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		*name = project.RepoName(remote)
+	}
+	if _, ok := config.Find(*name); ok {
+		return fmt.Errorf("project %q already exists, choose another with --name", *name)
+	}
+	target := settings.ClonePath(remote)
+	if *path != "" {
+		target = resolvePath(*path)
+	}
+
+	for _, p := range config.Projects {
+		if p.Path == target {
+			return fmt.Errorf("%s is already registered as %q", target, p.Name)
+		}
+	}
+
+	// An existing checkout of the same repository is only registered
+	if _, err := os.Stat(target); err == nil {
+		if project.NormalizeRemote(project.GitRemote(target)) != project.NormalizeRemote(remote) {
+			return fmt.Errorf("%s already exists", target)
+		}
+		fmt.Printf("%s is already cloned\n", target)
+	} else if err := gitClone(remote, target, ""); err != nil {
+		return fmt.Errorf("failed to clone %s: %w", remote, err)
+	}
+
+	language := "other"
+	if languages := project.GuessLanguage(target); len(languages) > 0 {
+		language = languages[0]
+	}
+	return addProject(project.Project{Name: *name, Path: target, Language: language, Remote: remote})
+}
+
+func runRestoreAll(args []string) error {
+	fs := flag.NewFlagSet("restore-all", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "show what would be cloned")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	cloned, failed := 0, 0
+	for _, p := range config.Active() {
+		if _, err := os.Stat(p.Path); err == nil {
+			continue
+		}
+		if p.Remote == "" {
+			fmt.Printf("%s: %s is missing and no remote is recorded\n", p.Name, p.Path)
+			failed++
+			continue
+		}
+
+		fmt.Printf("%s: cloning %s into %s\n", p.Name, p.Remote, p.Path)
+		if *dryRun {
+			continue
+		}
+		if err := gitClone(p.Remote, p.Path, p.DefaultBranch); err != nil {
+			fmt.Printf("Warning: Failed to clone %s: %v\n", p.Remote, err)
+			failed++
+			continue
+		}
+		cloned++
+	}
+
+	if *dryRun {
+		return nil
+	}
+	fmt.Printf("Cloned %d project(s).\n", cloned)
+	if failed > 0 {
+		return fmt.Errorf("%d project(s) could not be restored", failed)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// exitCode ends a command with that exit status and no message, once the
+// caches are saved.
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+// commands maps subcommand names to their handlers. Anything not listed here
+// falls through to the legacy "asap-pm <name> <path> [language]" form.
+var commands = map[string]func(args []string) error{
+	"clone":         runClone,
+	"config":        runConfig,
+	"current":       runCurrent,
+	"daemon":        runDaemon,
+	"doctor":        runDoctor,
+	"env":           runEnv,
+	"exec":          runExec,
+	"export":        runExport,
+	"import":        runImport,
+	"list":          runList,
+	"mv":            runMove,
+	"new":           runNew,
+	"nvim-sessions": runNvimSessions,
+	"open":          runOpen,
+	"profile":       runProfile,
+	"prompt":        runPrompt,
+	"relocate":      runRelocate,
+	"restore":       runRestore,
+	"restore-all":   runRestoreAll,
+	"run":           runRun,
+	"serve":         runServe,
+	"sync":          runSync,
+	"worktree":      runWorktree,
+}
+
+// parseFlags parses args with fs, allowing flags to appear after positional
+// arguments, and returns the positional ones.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	all := fs.Bool("all", false, "include archived projects")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := config.Problems(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\nRun \"asap-pm doctor\" or fix the file.\n", err)
+	}
+
+	// Pinned projects first, same as the manage view
+	var pinned, rest []project.Project
+	for _, p := range config.Projects {
+		if p.Archived && !*all {
+			continue
+		}
+		if p.Pinned {
+			pinned = append(pinned, p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, p := range append(pinned, rest...) {
+		mark := " "
+		if p.Pinned {
+			mark = "*"
+		}
+		status := ""
+		if p.Archived {
+			status = "[archived]"
+		}
+		_, _ = fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\n", mark, p.Name, p.Path, p.Language, status)
+	}
+	return w.Flush()
+}
+
+func runRestore(args []string) error {
+	unlock, err := project.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Without a name, show what can be restored
+	if len(args) == 0 {
+		if len(config.Trash) == 0 {
+			fmt.Println("Trash is empty")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, t := range config.Trash {
+			_, _ = fmt.Fprintf(w, "%s\t%s\tdeleted %s\n", t.Name, t.Path, t.DeletedAt.Format("2006-01-02 15:04"))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Println("\nUsage: asap-pm restore <name>")
+		return nil
+	}
+
+	var restored []project.Project
+	for _, name := range args {
+		p, err := config.RestoreFromTrash(name)
+		if err != nil {
+			return err
+		}
+		restored = append(restored, p)
+	}
+
+	if err := project.SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	unlock()
+
+	fmt.Println("Project restored successfully!")
+	for _, p := range restored {
+		runHooks(project.HookAdd, p)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"sapelkin.av/asap_project_manager/project"
+)
+
+const configUsage = `usage:
+  asap-pm config get [key]               show effective settings of the current project
+  asap-pm config set <key> <value> [--local]
+  asap-pm config edit [--local]
+
+--local uses .asap/config.toml of the current project instead of the global config.toml`
+
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return errors.New(configUsage)
+	}
+
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	local := fs.Bool("local", false, "use the project's .asap/config.toml")
+	rest, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	// Local settings are read from the project root, wherever in it this runs
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	root := ""
+	if idx, ok := project.FindCurrent(project.ActiveProfile(), config, cwd); ok {
+		root = config.Projects[idx].Path
+	}
+	load := project.LoadSettings
+	if root != "" {
+		load = func() (*project.Settings, error) { return project.LoadProjectSettings(root) }
+	}
+
+	var filePath string
+	if *local {
+		if root == "" {
+			return fmt.Errorf("%s is not inside a registered project, --local needs one", cwd)
+		}
+		filePath = project.LocalSettingsPath(root)
+	} else if filePath, err = project.SettingsPath(); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "get":
+		settings, err := load()
+		if err != nil {
+			return err
+		}
+		if len(rest) == 0 {
+			return toml.NewEncoder(os.Stdout).Encode(settings)
+		}
+		value, err := settings.Get(rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+
+	case "set":
+		if len(rest) != 2 {
+			return errors.New(configUsage)
+		}
+		if *local && project.IsGlobalSetting(rest[0]) {
+			return fmt.Errorf("%s can only be set in the global config", rest[0])
+		}
+		settings, err := project.ReadSettingsFile(filePath)
+		if err != nil {
+			return err
+		}
+		if err := settings.Set(rest[0], rest[1]); err != nil {
+			return err
+		}
+		return project.WriteSettingsFile(filePath, settings)
+
+	case "edit":
+		settings, err := load()
+		if err != nil {
+			return err
+		}
+		editor := strings.Fields(settings.Editor)
+		if len(editor) == 0 {
+			return fmt.Errorf("no editor configured")
+		}
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return err
+		}
+		cmd := exec.Command(editor[0], append(editor[1:], filePath)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+		// Report mistakes right away rather than on the next run
+		_, err = project.ReadSettingsFile(filePath)
+		return err
+	}
+
+	return errors.New(configUsage)
+}
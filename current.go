@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// currentProject is what "asap-pm current --json" prints.
+type currentProject struct {
+	project.Project
+	Profile string `json:"profile"`
+	Subdir  string `json:"subdir,omitempty"` // where the working directory is inside the project
+}
+
+func runCurrent(args []string) error {
+	fs := flag.NewFlagSet("current", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the project as JSON")
+	printPath := fs.Bool("path", false, "print the project path instead of its name")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *asJSON && *printPath {
+		return errors.New("usage: asap-pm current [--json | --path]")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Prompts call this everywhere, outside a project print nothing
+	idx, ok := project.FindCurrent(project.ActiveProfile(), config, cwd)
+	if !ok {
+		return exitCode(1)
+	}
+	p := config.Projects[idx]
+
+	switch {
+	case *asJSON:
+		current := currentProject{Project: p, Profile: project.ActiveProfile()}
+		root, _ := filepath.EvalSymlinks(p.Path)
+		dir, _ := filepath.EvalSymlinks(cwd)
+		if rel, err := filepath.Rel(root, dir); err == nil && rel != "." && filepath.IsLocal(rel) {
+			current.Subdir = rel
+		}
+		data, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case *printPath:
+		fmt.Println(p.Path)
+	default:
+		fmt.Println(p.Name)
+	}
+	return nil
+}
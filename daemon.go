@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// daemonDebounce lets editors and build tools finish writing before a
+// project is detected again.
+const daemonDebounce = 2 * time.Second
+
+// isBuildFile reports whether a change to name may change the language or
+// structure of a project.
+func isBuildFile(name string) bool {
+	switch name {
+	case "pom.xml", "go.mod", "package.json":
+		return true
+	}
+	return strings.HasPrefix(name, "build.gradle")
+}
+
+type daemon struct {
+	profile string
+	file    string // projects file of the profile
+	watcher *fsnotify.Watcher
+	detect  chan string // names of projects to detect again
+	hooks   bool        // run on_edit hooks when a language changes
+
+	mu      sync.Mutex
+	index   *project.Index
+	missing map[string]bool   // projects whose directory was moved or deleted
+	owners  map[string]string // watched directory to project name
+	roots   map[string]bool
+	timers  map[string]*time.Timer
+}
+
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	hooks := fs.Bool("hooks", false, "run on_edit hooks when the language of a project changes")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	profile := project.ActiveProfile()
+	file, err := project.ProfileFile(profile)
+	if err != nil {
+		return err
+	}
+	socket, err := project.DaemonSocket(profile)
+	if err != nil {
+		return err
+	}
+
+	// A socket nobody answers on is left over from a daemon that crashed
+	if conn, err := net.Dial("unix", socket); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("daemon already running on %s", socket)
+	}
+	_ = os.Remove(socket)
+	if err := os.MkdirAll(filepath.Dir(socket), 0755); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	defer listener.Close()
+	if err := os.Chmod(socket, 0600); err != nil {
+		return fmt.Errorf("failed to restrict socket: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch projects: %w", err)
+	}
+	defer watcher.Close()
+
+	d := &daemon{
+		profile: profile,
+		file:    file,
+		watcher: watcher,
+		detect:  make(chan string, 16),
+		hooks:   *hooks,
+		timers:  map[string]*time.Timer{},
+	}
+	if err := d.reload(); err != nil {
+		return err
+	}
+
+	go d.serve(listener)
+	go d.detectLoop()
+
+	log.Printf("Watching %d project(s) of profile %s, listening on %s", len(d.index.Entries), profile, socket)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	recheck := time.NewTicker(time.Minute)
+	defer recheck.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("watcher stopped")
+			}
+			d.handle(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return errors.New("watcher stopped")
+			}
+			// Events were lost, start over from the files
+			log.Printf("Warning: %v", err)
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				d.reloadLogged()
+			}
+		case <-recheck.C:
+			d.recheckRoots()
+		case <-signals:
+			log.Print("Stopping")
+			return nil
+		}
+	}
+}
+
+// reload reads the projects file again and watches the current project
+// roots, module directories and the directory of the projects file.
+func (d *daemon) reload() error {
+	config, err := project.LoadProfile(d.profile)
+	if err != nil {
+		return err
+	}
+	index := project.BuildIndex(config)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, dir := range d.watcher.WatchList() {
+		_ = d.watcher.Remove(dir)
+	}
+	d.index = index
+	d.missing = map[string]bool{}
+	d.owners = map[string]string{}
+	d.roots = map[string]bool{}
+
+	if err := d.watcher.Add(filepath.Dir(d.file)); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	for _, e := range index.Entries {
+		if _, err := os.Stat(e.Path); err != nil {
+			d.missing[e.Name] = true
+			continue
+		}
+		dirs := []string{e.Path}
+		for _, m := range e.Modules {
+			dirs = append(dirs, m.Dir)
+		}
+		for _, dir := range dirs {
+			if err := d.watcher.Add(dir); err != nil {
+				log.Printf("Warning: %v", err)
+				continue
+			}
+			d.owners[dir] = e.Name
+		}
+		d.roots[e.Path] = true
+	}
+	return nil
+}
+
+func (d *daemon) handle(event fsnotify.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if event.Name == d.file {
+		d.schedule("", d.reloadLogged)
+		return
+	}
+
+	// A watched directory reports its own removal or move under its name
+	dir, file := filepath.Dir(event.Name), filepath.Base(event.Name)
+	gone := event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)
+	if _, ok := d.owners[event.Name]; ok && gone {
+		dir, file = event.Name, ""
+	}
+
+	name, ok := d.owners[dir]
+	if !ok {
+		return
+	}
+	switch {
+	case file == "" && d.roots[dir]:
+		if !d.missing[name] {
+			log.Printf("%s: %s was moved or deleted", name, dir)
+		}
+		d.missing[name] = true
+	case file == "" || isBuildFile(file):
+		d.schedule(name, func() { d.detect <- name })
+	}
+}
+
+// schedule runs fn once changes for key have settled. The empty key stands
+// for the projects file. d.mu must be held.
+func (d *daemon) schedule(key string, fn func()) {
+	if timer, ok := d.timers[key]; ok {
+		timer.Stop()
+	}
+	d.timers[key] = time.AfterFunc(daemonDebounce, fn)
+}
+
+func (d *daemon) reloadLogged() {
+	if err := d.reload(); err != nil {
+		log.Printf("Error reloading projects: %v", err)
+	}
+}
+
+// detectLoop detects projects one at a time, the Java detector can take
+// a while and must not block the queries.
+func (d *daemon) detectLoop() {
+	for name := range d.detect {
+		if err := d.redetect(name); err != nil {
+			log.Printf("%s: %v", name, err)
+		}
+	}
+}
+
+// redetect updates the language of a project the way "doctor" would and
+// refreshes the structure of Java projects. Hooks only run with --hooks,
+// nobody confirmed the change.
+func (d *daemon) redetect(name string) error {
+	unlock, err := project.LockProfile(d.profile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := project.LoadProfile(d.profile)
+	if err != nil {
+		return err
+	}
+	idx, ok := config.Find(name)
+	if !ok {
+		return nil
+	}
+	p := config.Projects[idx]
+
+	detected := project.GuessLanguage(p.Path)
+	if project.KnownLanguage(p.Language) && len(detected) > 0 && !slices.Contains(detected, p.Language) {
+		log.Printf("%s: language changed from %s to %s", name, p.Language, detected[0])
+		config.Projects[idx].Language = detected[0]
+		if err := project.SaveProfile(d.profile, config); err != nil {
+			return err
+		}
+		unlock()
+		p = config.Projects[idx]
+		if d.hooks {
+			runHooks(project.HookEdit, p)
+		}
+	}
+
+	unlock()
+
+	if p.Language == "java" {
+		log.Printf("%s: detecting structure", name)
+		if err := detectStructure(p); err != nil {
+			return fmt.Errorf("failed to run Java project structure detector: %w", err)
+		}
+	}
+
+	// New modules need watching, saving above only schedules a reload
+	return d.reload()
+}
+
+// recheckRoots catches project directories that went away without an
+// event, such as when a parent directory was moved, and picks up those
+// that came back.
+func (d *daemon) recheckRoots() {
+	d.mu.Lock()
+	back := false
+	for _, e := range d.index.Entries {
+		_, err := os.Stat(e.Path)
+		switch {
+		case err == nil && d.missing[e.Name]:
+			back = true
+		case err != nil && !d.missing[e.Name]:
+			log.Printf("%s: %s was moved or deleted", e.Name, e.Path)
+			d.missing[e.Name] = true
+		}
+	}
+	d.mu.Unlock()
+
+	if back {
+		d.reloadLogged()
+	}
+}
+
+func (d *daemon) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.serveConn(conn)
+	}
+}
+
+func (d *daemon) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req project.DaemonRequest
+		var resp project.DaemonResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = fmt.Sprintf("invalid request: %v", err)
+		} else {
+			resp = d.answer(req)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (d *daemon) answer(req project.DaemonRequest) project.DaemonResponse {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var resp project.DaemonResponse
+	switch req.Method {
+	case project.DaemonLookup:
+		if !filepath.IsAbs(req.Dir) {
+			resp.Error = "lookup needs an absolute dir"
+			break
+		}
+		if entry, ok := d.index.Lookup(req.Dir); ok {
+			resp.Name, resp.Path, resp.Language = entry.Name, entry.Path, entry.Language
+			resp.Module = entry.Module(req.Dir)
+		}
+	case project.DaemonStatus:
+		for name := range d.missing {
+			resp.Missing = append(resp.Missing, name)
+		}
+		sort.Strings(resp.Missing)
+	default:
+		resp.Error = fmt.Sprintf("unknown method %q", req.Method)
+	}
+	return resp
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// relocateSearchDepth limits how deep doctor looks for moved repositories.
+const relocateSearchDepth = 4
+
+// ask prints question and reads a single lowercase answer from reader.
+func ask(reader *bufio.Reader, question string) string {
+	fmt.Print(question + " ")
+	answer, _ := reader.ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(answer))
+}
+
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	reportOnly := fs.Bool("report", false, "only report problems, do not offer fixes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Answers are collected first, the lock is only taken to apply them
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	issues := project.Diagnose(config)
+	if len(issues) == 0 {
+		fmt.Println("No problems found.")
+		return nil
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("%s: %s (%s)\n", doctorName(config, issue.Index), issue.Kind, issue.Detail)
+	}
+	if *reportOnly {
+		return fmt.Errorf("%d problem(s) found", len(issues))
+	}
+
+	settings, err := project.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	reader := bufio.NewReader(os.Stdin)
+	var fixes []doctorFix
+	fixed := map[int]bool{} // entries removed or dropped
+	edit := func(p project.Project, fn func(*project.Project)) {
+		fn(&config.Projects[slices.IndexFunc(config.Projects, func(q project.Project) bool { return q.Name == p.Name && q.Path == p.Path })])
+		fixes = append(fixes, doctorFix{name: p.Name, path: p.Path, edit: fn})
+	}
+
+	for _, issue := range issues {
+		p := config.Projects[issue.Index]
+		if fixed[issue.Index] {
+			continue
+		}
+
+		switch issue.Kind {
+		case project.IssueInvalid:
+			// The trash refuses invalid entries too, so they are dropped
+			if ask(reader, fmt.Sprintf("%s: invalid entry (%s). [d]elete permanently, [s]kip?", doctorName(config, issue.Index), issue.Detail)) == "d" {
+				fixes = append(fixes, doctorFix{name: p.Name, path: p.Path, drop: true})
+				fixed[issue.Index] = true
+			}
+
+		case project.IssueMissingPath:
+			switch ask(reader, fmt.Sprintf("%s: %s is missing. [r]elocate, [d]elete, [s]kip?", p.Name, p.Path)) {
+			case "r":
+				if p.Remote == "" {
+					fmt.Println("  No git remote recorded for this project, cannot search for it.")
+					continue
+				}
+				fmt.Printf("  Searching %s for %s...\n", strings.Join(settings.Roots(), ", "), p.Remote)
+				found, ok := project.FindRepoByRemote(settings.Roots(), settings.Ignore, p.Remote, relocateSearchDepth)
+				if !ok {
+					fmt.Println("  Not found.")
+					continue
+				}
+				if ask(reader, fmt.Sprintf("  Found at %s, use it? [y/n]", found)) == "y" {
+					edit(p, func(p *project.Project) { p.Path = found })
+				}
+			case "d":
+				fixes = append(fixes, doctorFix{name: p.Name, path: p.Path, remove: true})
+				fixed[issue.Index] = true
+			}
+
+		case project.IssueDuplicate:
+			if ask(reader, fmt.Sprintf("%s: duplicate entry (%s). [d]elete, [s]kip?", p.Name, issue.Detail)) == "d" {
+				fixes = append(fixes, doctorFix{name: p.Name, path: p.Path, remove: true})
+				fixed[issue.Index] = true
+			}
+
+		case project.IssueStaleStructure:
+			if ask(reader, fmt.Sprintf("%s: %s is stale (%s). [r]e-detect, [s]kip?", p.Name, project.StructurePath(p.Path), issue.Detail)) == "r" {
+				if err := detectStructure(p); err != nil {
+					fmt.Printf("  Warning: Failed to run Java project structure detector: %v\n", err)
+				}
+			}
+
+		case project.IssueLanguageChanged:
+			detected := project.GuessLanguage(p.Path)
+			if len(detected) == 0 {
+				continue
+			}
+			if ask(reader, fmt.Sprintf("%s: language %q is no longer detected. [r]e-detect as %q, [s]kip?", p.Name, p.Language, detected[0])) == "r" {
+				edit(p, func(p *project.Project) { p.Language = detected[0] })
+			}
+		}
+	}
+
+	// Offer to record remotes of healthy repositories so they can be found
+	// if moved or cloned again
+	for i, p := range config.Projects {
+		if p.Remote != "" && p.DefaultBranch != "" || fixed[i] || p.Path == "" {
+			continue
+		}
+		remote, branch := cmp.Or(p.Remote, project.GitRemote(p.Path)), cmp.Or(p.DefaultBranch, project.GitDefaultBranch(p.Path))
+		if remote == p.Remote && branch == p.DefaultBranch {
+			continue
+		}
+		if ask(reader, fmt.Sprintf("%s: record remote %q and default branch %q? [y/n]", p.Name, remote, branch)) == "y" {
+			edit(p, func(p *project.Project) { p.Remote, p.DefaultBranch = remote, branch })
+		}
+	}
+
+	if len(fixes) == 0 {
+		return nil
+	}
+
+	// Apply the answers to the file as it is now, entries another process
+	// changed in the meantime are skipped
+	var removed []project.Project
+	err = project.UpdateProfile(project.ActiveProfile(), func(config *project.Config) error {
+		for _, fix := range fixes {
+			idx := slices.IndexFunc(config.Projects, func(p project.Project) bool { return p.Name == fix.name && p.Path == fix.path })
+			switch {
+			case idx < 0:
+				fmt.Printf("%s: changed by another process, skipped\n", cmp.Or(fix.name, fix.path))
+			case fix.drop:
+				config.Remove(idx)
+			case fix.remove:
+				removed = append(removed, config.Projects[idx])
+				config.MoveToTrash(idx)
+			default:
+				fix.edit(&config.Projects[idx])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Println("Config updated.")
+	for _, p := range removed {
+		runHooks(project.HookRemove, p)
+	}
+	return nil
+}
+
+// doctorFix is an answer given to doctor. Entries are found again by name
+// and path, the file may have changed while the user answered.
+type doctorFix struct {
+	name, path   string
+	edit         func(*project.Project)
+	remove, drop bool // to the trash, or gone for good
+}
+
+// doctorName names the project at idx, which may lack a name.
+func doctorName(config *project.Config, idx int) string {
+	if name := config.Projects[idx].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("projects[%d]", idx)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// setEntries applies key=value flags to m, removing keys with empty values.
+func setEntries(m map[string]string, entries varFlags) map[string]string {
+	if len(entries) == 0 {
+		return m
+	}
+	if m == nil {
+		m = map[string]string{}
+	}
+	for key, value := range entries {
+		if value == "" {
+			delete(m, key)
+		} else {
+			m[key] = value
+		}
+	}
+	return m
+}
+
+func runEnv(args []string) error {
+	fs := flag.NewFlagSet("env", flag.ExitOnError)
+	set := varFlags{}
+	fs.Var(set, "set", "set a variable as KEY=VALUE, empty value removes it, may be repeated")
+	pin := varFlags{}
+	fs.Var(pin, "pin", "pin a toolchain as tool=version, empty version removes it, may be repeated")
+	envFile := fs.String("env-file", "", "dotenv file to load, relative to the project (\"-\" removes it)")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("usage: asap-pm env <name> [--set KEY=VALUE] [--pin tool=version] [--env-file <path>]")
+	}
+	for key := range set {
+		if !project.ValidEnvName(key) {
+			return fmt.Errorf("invalid variable name %q", key)
+		}
+	}
+
+	// Hooks may call asap-pm again, the lock is released before they run
+	update := len(set) > 0 || len(pin) > 0 || *envFile != ""
+	unlock := func() {}
+	if update {
+		if unlock, err = project.LockConfig(); err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	idx, ok := config.Find(args[0])
+	if !ok {
+		return fmt.Errorf("project %q not found", args[0])
+	}
+	p := &config.Projects[idx]
+
+	if update {
+		p.Env = setEntries(p.Env, set)
+		p.Toolchains = setEntries(p.Toolchains, pin)
+		switch *envFile {
+		case "":
+		case "-":
+			p.EnvFile = ""
+		default:
+			p.EnvFile = *envFile
+		}
+		if err := project.SaveConfig(config); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		unlock()
+		runHooks(project.HookEdit, *p)
+		return nil
+	}
+
+	// Export statements for eval "$(asap-pm env <name>)"
+	vars, err := project.ProjectEnv(*p)
+	if err != nil {
+		return err
+	}
+	for _, t := range project.Toolchains(*p) {
+		if t.Dir == "" {
+			fmt.Printf("# %s %s (%s) is not installed\n", t.Tool, t.Version, t.Source)
+		}
+	}
+	for _, v := range vars {
+		fmt.Printf("export %s=%s\n", v[0], project.ShellQuote(v[1]))
+	}
+	return nil
+}
+
+func runExec(args []string) error {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		return errors.New("usage: asap-pm exec <name> -- <command> [args...]")
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	idx, ok := config.Find(args[0])
+	if !ok {
+		return fmt.Errorf("project %q not found", args[0])
+	}
+	p := config.Projects[idx]
+
+	env, err := project.Environ(p)
+	if err != nil {
+		return err
+	}
+
+	// The shell looks the command up in the project PATH
+	cmd := exec.Command("sh", append([]string{"-c", `exec "$@"`, "sh"}, args[1:]...)...)
+	cmd.Dir = p.Path
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitCode(exitErr.ExitCode())
+		}
+		return err
+	}
+	return nil
+}
+
+// projectDetails describes p for the detail view of the TUI.
+func projectDetails(p project.Project) string {
+	s := fmt.Sprintf("%s\n\nPath:     %s\nLanguage: %s\n", p.Name, p.Path, p.Language)
+	if p.Remote != "" {
+		s += fmt.Sprintf("Remote:   %s\n", p.Remote)
+	}
+	if len(p.Tags) > 0 {
+		s += fmt.Sprintf("Tags:     %s\n", strings.Join(p.Tags, ", "))
+	}
+	if p.EnvFile != "" {
+		s += fmt.Sprintf("Env file: %s\n", p.EnvFile)
+	}
+	if len(p.Env) > 0 {
+		s += fmt.Sprintf("Env:      %s\n", strings.Join(slices.Sorted(maps.Keys(p.Env)), ", "))
+	}
+
+	toolchains := project.Toolchains(p)
+	if len(toolchains) == 0 {
+		return s + "\nNo toolchains pinned"
+	}
+	s += "\nToolchains:\n"
+	for _, t := range toolchains {
+		status := "installed"
+		if t.Dir == "" {
+			status = "not installed"
+		}
+		s += fmt.Sprintf("  %-8s %-10s %-14s %s\n", t.Tool, t.Version, status, t.Source)
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sapelkin.av/asap_project_manager/formats"
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "", "output format: "+strings.Join(formats.ExporterNames(), ", "))
+	output := fs.String("o", "", "write to file instead of stdout")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	exporter, ok := formats.LookupExporter(*format)
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected one of: %s", *format, strings.Join(formats.ExporterNames(), ", "))
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(resolvePath(*output))
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+		w = file
+	}
+
+	return exporter.Export(w, config.Active())
+}
@@ -0,0 +1,100 @@
+// Package formats converts the project registry to and from the formats of
+// other tools.
+package formats
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// Exporter writes projects in the format of another tool.
+type Exporter interface {
+	// Name is the value given to "asap-pm export --format".
+	Name() string
+	Export(w io.Writer, projects []project.Project) error
+}
+
+var exporters = map[string]Exporter{}
+
+// RegisterExporter makes e available by its name. Exporters in this package
+// register themselves in init.
+func RegisterExporter(e Exporter) {
+	exporters[e.Name()] = e
+}
+
+func LookupExporter(name string) (Exporter, bool) {
+	e, ok := exporters[name]
+	return e, ok
+}
+
+func ExporterNames() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Importer reads the projects known to another tool. Only names, paths and
+// whatever else the tool records are filled in; languages are detected by
+// the caller.
+type Importer interface {
+	// Name is the value given to "asap-pm import --from".
+	Name() string
+	// DefaultPath is where the tool keeps its data, used when no path is
+	// given. It is empty if there is no usual location.
+	DefaultPath() string
+	Import(path string) ([]project.Project, error)
+}
+
+var importers = map[string]Importer{}
+
+// RegisterImporter makes i available by its name.
+func RegisterImporter(i Importer) {
+	importers[i.Name()] = i
+}
+
+func LookupImporter(name string) (Importer, bool) {
+	i, ok := importers[name]
+	return i, ok
+}
+
+func ImporterNames() []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// homeRelative replaces the home directory prefix of path with prefix, as
+// tools like JetBrains IDEs store paths.
+func homeRelative(path, prefix string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, home); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		return prefix + rest
+	}
+	return path
+}
+
+// expandHome is the inverse of homeRelative.
+func expandHome(path, prefix string) string {
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + rest
+}
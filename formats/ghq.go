@@ -0,0 +1,53 @@
+package formats
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func init() {
+	RegisterImporter(ghq{})
+}
+
+// ghq finds the repositories under a ghq root, laid out as host/owner/repo.
+type ghq struct{}
+
+func (ghq) Name() string { return "ghq" }
+
+func (ghq) DefaultPath() string {
+	if out, err := exec.Command("ghq", "root").Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	if root := os.Getenv("GHQ_ROOT"); root != "" {
+		return root
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "ghq")
+}
+
+func (ghq) Import(root string) ([]project.Project, error) {
+	repos, err := filepath.Glob(filepath.Join(root, "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []project.Project
+	for _, repo := range repos {
+		if !project.IsGitRepo(repo) {
+			continue
+		}
+		projects = append(projects, project.Project{
+			Name:   filepath.Base(repo),
+			Path:   repo,
+			Remote: project.GitRemote(repo),
+		})
+	}
+	return projects, nil
+}
@@ -0,0 +1,127 @@
+package formats
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func init() {
+	RegisterExporter(jetbrainsRecent{})
+}
+
+// jetbrainsRecent writes options/recentProjects.xml of JetBrains IDEs.
+type jetbrainsRecent struct{}
+
+type jbApplication struct {
+	XMLName   xml.Name    `xml:"application"`
+	Component jbComponent `xml:"component"`
+}
+
+type jbComponent struct {
+	Name    string     `xml:"name,attr"`
+	Options []jbOption `xml:"option"`
+}
+
+type jbOption struct {
+	Name string `xml:"name,attr"`
+	Map  *jbMap `xml:"map"`
+}
+
+type jbMap struct {
+	Entries []jbEntry `xml:"entry"`
+}
+
+type jbEntry struct {
+	Key   string   `xml:"key,attr"`
+	Value *jbValue `xml:"value"`
+}
+
+type jbValue struct {
+	MetaInfo jbMetaInfo `xml:"RecentProjectMetaInfo"`
+}
+
+type jbMetaInfo struct {
+	FrameTitle string `xml:"frameTitle,attr"`
+}
+
+const jbUserHome = "$USER_HOME$"
+
+func (jetbrainsRecent) Name() string { return "jetbrains" }
+
+func (jetbrainsRecent) Export(w io.Writer, projects []project.Project) error {
+	entries := make([]jbEntry, 0, len(projects))
+	for _, p := range projects {
+		entries = append(entries, jbEntry{
+			Key:   homeRelative(p.Path, jbUserHome),
+			Value: &jbValue{MetaInfo: jbMetaInfo{FrameTitle: p.Name}},
+		})
+	}
+
+	app := jbApplication{Component: jbComponent{
+		Name:    "RecentProjectsManager",
+		Options: []jbOption{{Name: "additionalInfo", Map: &jbMap{Entries: entries}}},
+	}}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(app); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func init() {
+	RegisterImporter(jetbrainsRecent{})
+}
+
+// DefaultPath picks the recentProjects.xml of the most recently used IDE.
+func (jetbrainsRecent) DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "JetBrains", "*", "options", "recentProjects.xml"))
+
+	var newest string
+	var newestTime time.Time
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.ModTime().After(newestTime) {
+			newest, newestTime = match, info.ModTime()
+		}
+	}
+	return newest
+}
+
+func (jetbrainsRecent) Import(path string) ([]project.Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var app jbApplication
+	if err := xml.Unmarshal(data, &app); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var projects []project.Project
+	for _, option := range app.Component.Options {
+		if option.Name != "additionalInfo" || option.Map == nil {
+			continue
+		}
+		for _, entry := range option.Map.Entries {
+			projectPath := filepath.Clean(expandHome(entry.Key, jbUserHome))
+			projects = append(projects, project.Project{Name: filepath.Base(projectPath), Path: projectPath})
+		}
+	}
+	return projects, nil
+}
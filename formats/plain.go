@@ -0,0 +1,44 @@
+package formats
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func init() {
+	RegisterExporter(zoxideSeed{})
+	RegisterExporter(repoList{})
+}
+
+// zoxideSeed writes the z database format, loaded with
+// "zoxide import --from=z <file>".
+type zoxideSeed struct{}
+
+func (zoxideSeed) Name() string { return "zoxide" }
+
+func (zoxideSeed) Export(w io.Writer, projects []project.Project) error {
+	now := time.Now().Unix()
+	for _, p := range projects {
+		if _, err := fmt.Fprintf(w, "%s|1|%d\n", p.Path, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repoList writes one repository path per line, as used by mr and myrepos.
+type repoList struct{}
+
+func (repoList) Name() string { return "repos" }
+
+func (repoList) Export(w io.Writer, projects []project.Project) error {
+	for _, p := range projects {
+		if _, err := fmt.Fprintln(w, p.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
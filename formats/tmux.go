@@ -0,0 +1,153 @@
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func init() {
+	RegisterExporter(tmuxinator{})
+	RegisterExporter(sesh{})
+}
+
+// tmuxinator writes one YAML document per project. Split them into
+// ~/.config/tmuxinator/<name>.yml, the comment before each names the file.
+type tmuxinator struct{}
+
+func (tmuxinator) Name() string { return "tmuxinator" }
+
+func (tmuxinator) Export(w io.Writer, projects []project.Project) error {
+	for i, p := range projects {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w, "---"); err != nil {
+				return err
+			}
+		}
+		// JSON strings are valid double-quoted YAML scalars
+		name, _ := json.Marshal(p.Name)
+		root, _ := json.Marshal(p.Path)
+		_, err := fmt.Fprintf(w, "# %s.yml\nname: %s\nroot: %s\nwindows:\n  - editor:\n  - shell:\n", p.Name, name, root)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sesh writes the [[session]] tables of ~/.config/sesh/sesh.toml.
+type sesh struct{}
+
+type seshSession struct {
+	Name string `toml:"name"`
+	Path string `toml:"path"`
+}
+
+func (sesh) Name() string { return "sesh" }
+
+func (sesh) Export(w io.Writer, projects []project.Project) error {
+	var config struct {
+		Sessions []seshSession `toml:"session"`
+	}
+	for _, p := range projects {
+		config.Sessions = append(config.Sessions, seshSession{Name: p.Name, Path: project.CompactPath(p.Path)})
+	}
+	return toml.NewEncoder(w).Encode(config)
+}
+
+func init() {
+	RegisterImporter(tmuxinator{})
+	RegisterImporter(sesh{})
+}
+
+func (tmuxinator) DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tmuxinator")
+}
+
+// Import reads the top-level name and root of every .yml file in the
+// directory path, or of the single file path.
+func (tmuxinator) Import(path string) ([]project.Project, error) {
+	files := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		files, _ = filepath.Glob(filepath.Join(path, "*.yml"))
+	}
+
+	var projects []project.Project
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		p := project.Project{Name: strings.TrimSuffix(filepath.Base(file), ".yml")}
+		for _, line := range strings.Split(string(data), "\n") {
+			key, value, found := strings.Cut(line, ":")
+			if !found || strings.HasPrefix(key, " ") {
+				continue
+			}
+			switch key {
+			case "name":
+				p.Name = yamlScalar(value)
+			case "root", "project_root":
+				p.Path = project.ExpandPath(yamlScalar(value))
+			}
+		}
+		if p.Path != "" {
+			projects = append(projects, p)
+		}
+	}
+	return projects, nil
+}
+
+// yamlScalar unquotes a simple YAML scalar and drops a trailing comment.
+func yamlScalar(value string) string {
+	value = strings.TrimSpace(value)
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+func (sesh) DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "sesh", "sesh.toml")
+}
+
+func (sesh) Import(path string) ([]project.Project, error) {
+	var config struct {
+		Sessions []seshSession `toml:"session"`
+	}
+	if _, err := toml.DecodeFile(path, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var projects []project.Project
+	for _, s := range config.Sessions {
+		if s.Path != "" {
+			projects = append(projects, project.Project{Name: s.Name, Path: project.ExpandPath(s.Path)})
+		}
+	}
+	return projects, nil
+}
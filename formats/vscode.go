@@ -0,0 +1,169 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func init() {
+	RegisterExporter(vscodeWorkspace{})
+}
+
+// vscodeWorkspace writes a multi-root .code-workspace file.
+type vscodeWorkspace struct{}
+
+type workspaceFolder struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path"`
+}
+
+type workspaceFile struct {
+	Folders  []workspaceFolder `json:"folders"`
+	Settings map[string]any    `json:"settings"`
+}
+
+func (vscodeWorkspace) Name() string { return "vscode" }
+
+func (vscodeWorkspace) Export(w io.Writer, projects []project.Project) error {
+	workspace := workspaceFile{Folders: []workspaceFolder{}, Settings: map[string]any{}}
+	for _, p := range projects {
+		workspace.Folders = append(workspace.Folders, workspaceFolder{Name: p.Name, Path: p.Path})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(workspace)
+}
+
+func init() {
+	RegisterImporter(vscodeProjectManager{})
+	RegisterImporter(vscodeWorkspace{})
+}
+
+// vscodeProjectManager reads projects.json of the Project Manager extension.
+type vscodeProjectManager struct{}
+
+type pmProject struct {
+	Name     string   `json:"name"`
+	RootPath string   `json:"rootPath"`
+	Tags     []string `json:"tags"`
+	Enabled  *bool    `json:"enabled"`
+}
+
+func (vscodeProjectManager) Name() string { return "vscode-pm" }
+
+func (vscodeProjectManager) DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "Code", "User", "globalStorage", "alefragnani.project-manager", "projects.json")
+}
+
+func (vscodeProjectManager) Import(path string) ([]project.Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []pmProject
+	if err := json.Unmarshal(stripJSONC(data), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var projects []project.Project
+	for _, e := range entries {
+		if e.Enabled != nil && !*e.Enabled {
+			continue
+		}
+		projects = append(projects, project.Project{
+			Name: e.Name,
+			Path: project.ExpandPath(expandHome(e.RootPath, "$home")),
+			Tags: e.Tags,
+		})
+	}
+	return projects, nil
+}
+
+func (vscodeWorkspace) DefaultPath() string { return "" }
+
+// Import reads the folders of a .code-workspace file. Relative folder paths
+// are relative to the file.
+func (vscodeWorkspace) Import(path string) ([]project.Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var workspace workspaceFile
+	if err := json.Unmarshal(stripJSONC(data), &workspace); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var projects []project.Project
+	for _, folder := range workspace.Folders {
+		folderPath := folder.Path
+		if !filepath.IsAbs(folderPath) && !strings.HasPrefix(folderPath, "~") {
+			folderPath = filepath.Join(filepath.Dir(path), folderPath)
+		}
+		folderPath = filepath.Clean(project.ExpandPath(folderPath))
+
+		name := folder.Name
+		if name == "" {
+			name = filepath.Base(folderPath)
+		}
+		projects = append(projects, project.Project{Name: name, Path: folderPath})
+	}
+	return projects, nil
+}
+
+// stripJSONC removes the comments and trailing commas VS Code allows in its
+// JSON files.
+func stripJSONC(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == ']' || c == '}':
+			// Drop a trailing comma before the closing bracket
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package formats
+
+import "testing"
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"plain", `{"a": 1}`, `{"a": 1}`},
+		{"line comment", "{\n// note\n\"a\": 1 // end\n}", "{\n\n\"a\": 1 \n}"},
+		{"block comment", `{/* x */"a": /* y */1}`, `{"a": 1}`},
+		{"trailing commas", "[1, 2,\n]", "[1, 2\n]"},
+		{"trailing comma in object", `{"a": [1,], "b": 2, }`, `{"a": [1], "b": 2 }`},
+		{"comment markers in strings", `{"url": "http://x/*y*/", "c": "a,]"}`, `{"url": "http://x/*y*/", "c": "a,]"}`},
+		{"escaped quote", `{"a": "say \"//hi\""}`, `{"a": "say \"//hi\""}`},
+		{"unterminated block comment", `{"a": 1 /* x`, `{"a": 1 `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(stripJSONC([]byte(tt.data))); got != tt.want {
+				t.Errorf("stripJSONC(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
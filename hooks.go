@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// runHooks runs the hooks for event from the command line. The change that
+// triggered them is already saved, so failures are only reported.
+func runHooks(event string, p project.Project) {
+	if err := project.RunHooks(os.Stdout, event, project.ActiveProfile(), p); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// hookFinishedMsg is sent when hooks started from the TUI are done.
+type hookFinishedMsg struct {
+	err error
+}
+
+// hookCmd runs the hooks for event in the background of the TUI. Their
+// output would garble the screen and is only shown when they fail.
+func hookCmd(event, profile string, p project.Project) tea.Cmd {
+	return func() tea.Msg {
+		return hookFinishedMsg{project.RunHooks(nil, event, profile, p)}
+	}
+}
+
+// detectStructure runs the Java structure detector for p and then the
+// on_detect hooks.
+func detectStructure(p project.Project) error {
+	if err := runJavaStructureDetector(p.Path); err != nil {
+		return err
+	}
+	runHooks(project.HookDetect, p)
+	return nil
+}
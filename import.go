@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"sapelkin.av/asap_project_manager/formats"
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// gitClone clones remote into path, showing git's progress output. A
+// non-empty branch is checked out instead of the remote's HEAD.
+func gitClone(remote, path, branch string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	args := []string{"clone"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	cmd := exec.Command("git", append(args, "--", remote, path)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// loadRegistry reads the shared registry at source and returns its projects
+// with the function placing them on disk.
+func loadRegistry(settings *project.Settings, source string) ([]project.Project, func(project.Project) string, error) {
+	registry, err := project.DecodeConfigFile(project.ExpandPath(source))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	// Shared registries describe where a project lives relative to the
+	// clone root, if at all
+	cloneRoot := project.ExpandPath(settings.CloneRoot)
+	place := func(p project.Project) string {
+		switch {
+		case p.Path == "" && p.Remote != "":
+			return settings.ClonePath(p.Remote)
+		case p.Path == "":
+			return filepath.Join(cloneRoot, p.Name)
+		case strings.HasPrefix(p.Path, "~") || filepath.IsAbs(project.ExpandEnv(p.Path)):
+			return project.ExpandPath(p.Path)
+		default:
+			return filepath.Join(cloneRoot, p.Path)
+		}
+	}
+	return registry.Projects, place, nil
+}
+
+// cloneRegistry clones the repositories of projects the registry at source
+// would add to config and that are missing on disk. It runs before the
+// projects file is locked, config is left alone. It returns the names of
+// the projects whose clone failed.
+func cloneRegistry(config *project.Config, settings *project.Settings, source string, update bool) (map[string]bool, error) {
+	projects, place, err := loadRegistry(settings, source)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := *config
+	preview.Projects = slices.Clone(config.Projects)
+	failed := map[string]bool{}
+	for _, result := range preview.Merge(projects, source, update, place) {
+		p := result.Project
+		if result.Action != project.MergeAdd || p.Remote == "" {
+			continue
+		}
+		if _, err := os.Stat(p.Path); !os.IsNotExist(err) {
+			continue
+		}
+		if err := gitClone(p.Remote, p.Path, p.DefaultBranch); err != nil {
+			fmt.Printf("Warning: Failed to clone %s, skipping %s: %v\n", p.Remote, p.Name, err)
+			failed[p.Name] = true
+		}
+	}
+	return failed, nil
+}
+
+// importRegistry merges the shared registry at source into config. Projects
+// in skip, whose clone failed, are not registered. It returns the indices
+// of the added projects.
+func importRegistry(config *project.Config, settings *project.Settings, source string, update, dryRun bool, skip map[string]bool) ([]int, error) {
+	projects, place, err := loadRegistry(settings, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var added, failed []int
+	for _, result := range config.Merge(projects, source, update, place) {
+		if result.Action == project.MergeAdd && skip[result.Project.Name] {
+			failed = append(failed, result.Index)
+			continue
+		}
+		fmt.Printf("%-8s %s (%s)\n", result.Action, result.Project.Name, result.Project.Path)
+		if result.Action != project.MergeAdd || dryRun {
+			continue
+		}
+
+		added = append(added, result.Index)
+		p := &config.Projects[result.Index]
+		if p.Language == "" {
+			if languages := project.GuessLanguage(p.Path); len(languages) > 0 {
+				p.Language = languages[0]
+			}
+		}
+	}
+
+	// Added projects are at the end, drop the failed ones from the back
+	for i := len(failed) - 1; i >= 0; i-- {
+		config.Projects = slices.Delete(config.Projects, failed[i], failed[i]+1)
+		for j := range added {
+			if added[j] > failed[i] {
+				added[j]--
+			}
+		}
+	}
+	return added, nil
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	update := fs.Bool("update", false, "update projects that are already registered")
+	noClone := fs.Bool("no-clone", false, "do not clone missing repositories")
+	dryRun := fs.Bool("dry-run", false, "show changes without applying them")
+	from := fs.String("from", "", "import from another tool: "+strings.Join(formats.ImporterNames(), ", "))
+	yes := fs.Bool("yes", false, "do not ask for confirmation with --from")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *from != "" {
+		return importFromTool(*from, args, *yes, *dryRun)
+	}
+	if len(args) != 1 {
+		return errors.New("usage: asap-pm import <file|dir> [--update] [--no-clone] [--dry-run]\n       asap-pm import --from <tool> [path] [--yes] [--dry-run]")
+	}
+
+	// A directory, such as a checkout of the platform repo, holds projects.toml
+	source := resolvePath(args[0])
+	if info, err := os.Stat(source); err != nil {
+		return err
+	} else if info.IsDir() {
+		source = filepath.Join(source, "projects.toml")
+	}
+
+	source = project.CompactPath(source)
+	settings, err := project.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	// Clone before locking, other commands must not wait for git
+	var failed map[string]bool
+	if !*noClone && !*dryRun {
+		config, err := project.LoadConfig()
+		if err != nil {
+			return err
+		}
+		if failed, err = cloneRegistry(config, settings, source, *update); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := project.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	added, err := importRegistry(config, settings, source, *update, *dryRun, failed)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		return nil
+	}
+	if err := project.SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	unlock()
+	fmt.Println("Registry imported successfully!")
+	for _, i := range added {
+		runHooks(project.HookAdd, config.Projects[i])
+	}
+	return nil
+}
+
+// importFromTool registers the projects another tool knows about, after
+// showing what would be added and asking for confirmation.
+func importFromTool(name string, args []string, yes, dryRun bool) error {
+	importer, ok := formats.LookupImporter(name)
+	if !ok {
+		return fmt.Errorf("unknown tool %q, expected one of: %s", name, strings.Join(formats.ImporterNames(), ", "))
+	}
+
+	path := importer.DefaultPath()
+	if len(args) > 0 {
+		path = resolvePath(args[0])
+	}
+	if path == "" {
+		return fmt.Errorf("no default location for %s, please give a path", name)
+	}
+
+	found, err := importer.Import(path)
+	if err != nil {
+		return err
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Skip known paths and give colliding names a numeric suffix
+	known := map[string]bool{}
+	names := map[string]bool{}
+	for _, p := range config.Projects {
+		known[filepath.Clean(p.Path)] = true
+		names[p.Name] = true
+	}
+
+	var added []project.Project
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, p := range found {
+		p.Path = filepath.Clean(p.Path)
+		status := "new"
+		if known[p.Path] {
+			status = "duplicate"
+		} else if _, err := os.Stat(p.Path); err != nil {
+			status = "missing"
+		} else {
+			base := p.Name
+			for i := 2; names[p.Name]; i++ {
+				p.Name = fmt.Sprintf("%s-%d", base, i)
+			}
+			if languages := project.GuessLanguage(p.Path); len(languages) > 0 {
+				p.Language = languages[0]
+			}
+			known[p.Path] = true
+			names[p.Name] = true
+			added = append(added, p)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", status, p.Name, p.Path, p.Language)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(added) == 0 {
+		fmt.Println("Nothing to import.")
+		return nil
+	}
+	if dryRun {
+		return nil
+	}
+	if !yes && ask(bufio.NewReader(os.Stdin), fmt.Sprintf("Import %d project(s)? [y/N]", len(added))) != "y" {
+		return nil
+	}
+
+	// The file may have changed while the user looked at the list
+	err = project.UpdateProfile(project.ActiveProfile(), func(config *project.Config) error {
+		added = slices.DeleteFunc(added, func(p project.Project) bool {
+			return slices.ContainsFunc(config.Projects, func(q project.Project) bool {
+				return q.Name == p.Name || filepath.Clean(q.Path) == p.Path
+			})
+		})
+		config.Projects = append(config.Projects, added...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Printf("Imported %d project(s).\n", len(added))
+	for _, p := range added {
+		runHooks(project.HookAdd, p)
+	}
+	return nil
+}
+
+func runSync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	noClone := fs.Bool("no-clone", false, "do not clone missing repositories")
+	dryRun := fs.Bool("dry-run", false, "show changes without applying them")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if len(config.Imports) == 0 {
+		fmt.Println("No imported registries to sync.")
+		return nil
+	}
+	settings, err := project.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	// Clone before locking, other commands must not wait for git
+	failed := map[string]bool{}
+	if !*noClone && !*dryRun {
+		for _, source := range config.Imports {
+			names, err := cloneRegistry(config, settings, source, true)
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			maps.Copy(failed, names)
+		}
+	}
+
+	unlock, err := project.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if config, err = project.LoadConfig(); err != nil {
+		return err
+	}
+	var added []int
+	for _, source := range config.Imports {
+		fmt.Printf("Syncing %s\n", source)
+		indices, err := importRegistry(config, settings, source, true, *dryRun, failed)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		added = append(added, indices...)
+	}
+	if *dryRun {
+		return nil
+	}
+	if err := project.SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	unlock()
+	fmt.Println("Registries synced successfully!")
+	for _, i := range added {
+		runHooks(project.HookAdd, config.Projects[i])
+	}
+	return nil
+}
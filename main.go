@@ -1,16 +1,22 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	_ "embed"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	"sapelkin.av/asap_project_manager/project"
@@ -22,6 +28,11 @@ var javaStructureScript string
 var titleCaser = cases.Title(language.English)
 
 func runJavaStructureDetector(projectPath string) error {
+	settings, err := project.LoadProjectSettings(projectPath)
+	if err != nil {
+		return err
+	}
+
 	// Debug: Check if embedded script is available
 	if javaStructureScript == "" {
 		return fmt.Errorf("embedded script is empty - embed failed")
@@ -53,34 +64,330 @@ func runJavaStructureDetector(projectPath string) error {
 
 	fmt.Printf("Debug: Running script at: %s in directory: %s\n", tmpScript.Name(), projectPath)
 
+	// The script rewrites the whole file, keep the tasks written by hand
+	tasks := project.StructureTasks(projectPath)
+
 	// Run the script in the project directory
-	cmd := exec.Command("bash", tmpScript.Name())
+	ctx, cancel := context.WithCancel(context.Background())
+	if settings.DetectTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), settings.DetectTimeout)
+	}
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "bash", tmpScript.Name())
 	cmd.Dir = projectPath
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	return cmd.Run()
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("detection timed out after %s", settings.DetectTimeout)
+		}
+		return err
+	}
+
+	return project.AppendStructureTasks(projectPath, tasks)
+}
+
+// addProject registers p in the active profile and, for Java projects, runs
+// the structure detector. The git remote and default branch are recorded so
+// the project can be cloned again elsewhere.
+func addProject(p project.Project) error {
+	unlock, err := project.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if _, exists := config.Find(p.Name); exists {
+		return fmt.Errorf("project %q already exists", p.Name)
+	}
+
+	// Worktrees of a registered repository are grouped under it
+	if main, ok := project.MainWorktree(p.Path); ok && p.Parent == "" {
+		for _, parent := range config.Projects {
+			if parent.Path == main {
+				p.Parent, p.Branch = parent.Name, project.GitBranch(p.Path)
+				p.DefaultBranch = cmp.Or(p.DefaultBranch, parent.DefaultBranch)
+			}
+		}
+	}
+
+	if p.Remote == "" {
+		p.Remote = project.GitRemote(p.Path)
+	}
+	if p.DefaultBranch == "" {
+		p.DefaultBranch = project.GitDefaultBranch(p.Path)
+	}
+
+	config.Projects = append(config.Projects, p)
+	if err := project.SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	unlock()
+
+	fmt.Println("Project added successfully!")
+	runHooks(project.HookAdd, p)
+
+	// Launch Java project structure detector if it's a Java project
+	if p.Language == "java" {
+		fmt.Println("Detecting Java project structure...")
+		if err := detectStructure(p); err != nil {
+			fmt.Printf("Warning: Failed to run Java project structure detector: %v\n", err)
+		} else {
+			fmt.Println("Java project structure detected and saved.")
+		}
+	}
+	return nil
 }
 
 type projectItem struct {
-	project project.Project
+	project     project.Project
+	profile     string
+	index       int // position in the profile's config.Projects
+	showProfile bool
+	nested      bool // listed under the project it is a worktree of
+	missing     bool // reported moved or deleted by the daemon
 }
 
 func (p projectItem) FilterValue() string {
-	return fmt.Sprintf("%s - %s (%s)", p.project.Name, p.project.Path, p.project.Language)
+	return fmt.Sprintf("%s - %s (%s) %s %s", p.project.Name, p.project.Path, p.project.Language, p.project.Branch, strings.Join(p.project.Tags, " "))
 }
 
 func (p projectItem) Title() string {
-	return p.project.Name
+	title := p.project.Name
+	if p.project.Pinned {
+		title = "* " + title
+	}
+	if p.nested {
+		title = "└ " + title
+	}
+	return title
 }
 
 func (p projectItem) Description() string {
-	return fmt.Sprintf("%s (%s)", p.project.Path, p.project.Language)
+	desc := fmt.Sprintf("%s (%s)", p.project.Path, p.project.Language)
+	if p.project.Archived {
+		desc += " [archived]"
+	}
+	if p.missing {
+		desc += " [missing]"
+	}
+	if p.showProfile {
+		desc += " [" + p.profile + "]"
+	}
+	return desc
 }
 
+type deleteStep int
+
+const (
+	deleteNone deleteStep = iota
+	deleteConfirm
+	deleteFilesConfirm
+	deleteFilesFinal
+)
+
 type manageProjectsModel struct {
-	list     list.Model
-	projects []project.Project
+	list         list.Model
+	projects     []project.Project
+	showArchived bool
+	merged       bool // showing the projects of every profile
+	deleteStep   deleteStep
+	pending      projectItem
+	undo         []projectItem // projects trashed this session, newest last
+	status       string
+	palette      *taskPalette // open task palette, if any
+	details      string       // shown instead of the help until a key is pressed
+	worktrees    *worktreePicker
+}
+
+// reload rebuilds the list from projects while keeping session state. The
+// merged view re-reads every profile instead.
+func (m manageProjectsModel) reload(projects []project.Project) manageProjectsModel {
+	var nm manageProjectsModel
+	if m.merged {
+		nm = initialMergedManageModel(m.showArchived)
+	} else {
+		nm = initialManageModel(projects, m.showArchived)
+	}
+	nm.undo = m.undo
+	nm.status = m.status
+	return nm
+}
+
+// applyUpdate replaces the project called name in config with updated, after
+// the checks every edit goes through. Worktrees follow a rename.
+func applyUpdate(config *project.Config, name string, updated project.Project) error {
+	idx, ok := config.Find(name)
+	if !ok {
+		return fmt.Errorf("project %q no longer exists", name)
+	}
+	original := config.Projects[idx]
+
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+	if i, exists := config.Find(updated.Name); exists && i != idx {
+		return fmt.Errorf("project %q already exists", updated.Name)
+	}
+	if updated.Path != original.Path {
+		if info, err := os.Stat(updated.Path); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", updated.Path)
+		}
+	}
+	if updated.Parent != "" {
+		if updated.Parent == name || updated.Parent == updated.Name {
+			return fmt.Errorf("project %q cannot be its own parent", updated.Name)
+		}
+		if _, ok := config.Find(updated.Parent); !ok {
+			return fmt.Errorf("parent project %q not found", updated.Parent)
+		}
+	}
+	if updated.Source != "" && updated.Source != original.Source {
+		if _, err := os.Stat(project.ExpandPath(updated.Source)); err != nil {
+			return fmt.Errorf("registry %s not found", updated.Source)
+		}
+	}
+
+	config.Projects[idx] = updated
+	for i := range config.Projects {
+		if config.Projects[i].Parent == name {
+			config.Projects[i].Parent = updated.Name
+		}
+	}
+	return nil
+}
+
+// updateProject applies fn to the project of item in the saved config and
+// rebuilds the list from the result.
+func (m manageProjectsModel) updateProject(item projectItem, fn func(*project.Project)) (tea.Model, tea.Cmd) {
+	unlock, err := project.TryLockProfile(item.profile)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	defer unlock()
+
+	// Positions change when another process edits the file, names do not
+	config, err := project.LoadProfile(item.profile)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	idx, ok := config.Find(item.project.Name)
+	if !ok {
+		return m, nil
+	}
+
+	fn(&config.Projects[idx])
+
+	if err := project.SaveProfile(item.profile, config); err != nil {
+		m.status = fmt.Sprintf("Failed to save config: %v", err)
+		return m, nil
+	}
+
+	return m.reload(config.Projects), hookCmd(project.HookEdit, item.profile, config.Projects[idx])
+}
+
+// deleteProject removes the pending project from the registry. The entry goes
+// to the trash unless its files are removed from disk as well.
+func (m manageProjectsModel) deleteProject(withFiles bool) (tea.Model, tea.Cmd) {
+	m.deleteStep = deleteNone
+
+	unlock, err := project.TryLockProfile(m.pending.profile)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	defer unlock()
+
+	config, err := project.LoadProfile(m.pending.profile)
+	if err != nil {
+		return m, nil
+	}
+	idx, ok := config.Find(m.pending.project.Name)
+	if !ok {
+		return m, nil
+	}
+	proj := config.Projects[idx]
+
+	if withFiles {
+		config.Remove(idx)
+	} else {
+		config.MoveToTrash(idx)
+	}
+
+	// Files go only once the entry is gone, a failed save keeps both
+	if err := project.SaveProfile(m.pending.profile, config); err != nil {
+		m.status = fmt.Sprintf("Failed to save config: %v", err)
+		return m, nil
+	}
+
+	if withFiles {
+		if err := os.RemoveAll(proj.Path); err != nil {
+			m.status = fmt.Sprintf("Warning: deleted '%s' but failed to remove its files: %v", proj.Name, err)
+		} else {
+			m.status = fmt.Sprintf("Deleted '%s' and its files", proj.Name)
+		}
+	} else {
+		m.undo = append(m.undo, m.pending)
+		m.status = fmt.Sprintf("Moved '%s' to trash, press 'u' to undo", proj.Name)
+	}
+	return m.reload(config.Projects), hookCmd(project.HookRemove, m.pending.profile, proj)
+}
+
+// undoDelete restores the most recently trashed project of this session.
+func (m manageProjectsModel) undoDelete() (tea.Model, tea.Cmd) {
+	if len(m.undo) == 0 {
+		m.status = "Nothing to undo"
+		return m, nil
+	}
+	item := m.undo[len(m.undo)-1]
+	name := item.project.Name
+
+	unlock, err := project.TryLockProfile(item.profile)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	defer unlock()
+
+	config, err := project.LoadProfile(item.profile)
+	if err != nil {
+		return m, nil
+	}
+	restored, err := config.RestoreFromTrash(name)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	if err := project.SaveProfile(item.profile, config); err != nil {
+		m.status = fmt.Sprintf("Failed to save config: %v", err)
+		return m, nil
+	}
+
+	m.undo = m.undo[:len(m.undo)-1]
+	m.status = fmt.Sprintf("Restored '%s'", name)
+	return m.reload(config.Projects), hookCmd(project.HookAdd, item.profile, restored)
+}
+
+// launchFinishedMsg is sent when a launcher started from the TUI exits.
+type launchFinishedMsg struct {
+	err error
+}
+
+// selectProject moves the cursor to the project at index in profile.
+func (m manageProjectsModel) selectProject(profile string, index int) manageProjectsModel {
+	for i, item := range m.list.Items() {
+		if pi := item.(projectItem); pi.profile == profile && pi.index == index {
+			m.list.Select(i)
+		}
+	}
+	return m
 }
 
 func (m manageProjectsModel) Init() tea.Cmd {
@@ -91,7 +398,57 @@ func (m manageProjectsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.list.SetSize(msg.Width, msg.Height)
+	case launchFinishedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Launcher failed: %v", msg.err)
+		}
+		return m, nil
+	case taskFinishedMsg:
+		m.status = fmt.Sprintf("Task '%s' finished", msg.name)
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Task '%s' failed: %v", msg.name, msg.err)
+		}
+		return m, nil
+	case hookFinishedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Hook failed: %v", msg.err)
+		}
+		return m, nil
 	case tea.KeyMsg:
+		// Pending delete confirmation takes every key
+		if m.deleteStep != deleteNone {
+			if msg.String() != "y" {
+				m.deleteStep = deleteNone
+				m.status = "Delete cancelled"
+				return m, nil
+			}
+			switch m.deleteStep {
+			case deleteConfirm:
+				return m.deleteProject(false)
+			case deleteFilesConfirm:
+				m.deleteStep = deleteFilesFinal
+				return m, nil
+			case deleteFilesFinal:
+				return m.deleteProject(true)
+			}
+		}
+
+		// So does the task palette
+		if m.palette != nil {
+			return m.updatePalette(msg)
+		}
+		if m.worktrees != nil {
+			return m.updateWorktreePicker(msg)
+		}
+		if m.details != "" {
+			m.details = ""
+			return m, nil
+		}
+
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		m.status = ""
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
@@ -106,35 +463,77 @@ func (m manageProjectsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.projects = config.Projects
 			}
 			return initialAddModel(), nil
+		case "n":
+			return initialNewModel(), nil
 		case "e", "enter":
 			// Edit selected project
 			selectedItem := m.list.SelectedItem()
 			if selectedItem != nil {
 				if projItem, ok := selectedItem.(projectItem); ok {
-					return initialEditModel(projItem.project, m.list.Index()), nil
+					return initialEditModel(projItem.project, projItem.profile), nil
 				}
 			}
-		case "d":
-			// Delete selected project
-			selectedIdx := m.list.Index()
-			if selectedIdx >= 0 && selectedIdx < len(m.projects) {
-				// Load config
-				config, err := project.LoadConfig()
+		case "o":
+			// Open selected project with the default launcher
+			if projItem, ok := m.list.SelectedItem().(projectItem); ok {
+				cmd, err := launchCmd(projItem.project, "")
 				if err != nil {
+					m.status = err.Error()
 					return m, nil
 				}
-
-				// Remove project
-				config.Projects = append(config.Projects[:selectedIdx], config.Projects[selectedIdx+1:]...)
-
-				// Save config
-				if err := project.SaveConfig(config); err != nil {
-					return m, nil
+				return m, tea.Batch(
+					hookCmd(project.HookOpen, projItem.profile, projItem.project),
+					tea.ExecProcess(cmd, func(err error) tea.Msg {
+						return launchFinishedMsg{err}
+					}),
+				)
+			}
+		case "i":
+			// Show details and toolchains of selected project
+			if projItem, ok := m.list.SelectedItem().(projectItem); ok {
+				m.details = projectDetails(projItem.project)
+			}
+			return m, nil
+		case "w":
+			// Pick a worktree of selected project to open
+			if projItem, ok := m.list.SelectedItem().(projectItem); ok {
+				return m.openWorktreePicker(projItem)
+			}
+		case "t":
+			// Pick a task of the selected project to run
+			if projItem, ok := m.list.SelectedItem().(projectItem); ok {
+				return m.openPalette(projItem)
+			}
+		case "p":
+			// Pin or unpin selected project
+			if projItem, ok := m.list.SelectedItem().(projectItem); ok {
+				return m.updateProject(projItem, func(p *project.Project) {
+					p.Pinned = !p.Pinned
+				})
+			}
+		case "x":
+			// Archive or unarchive selected project
+			if projItem, ok := m.list.SelectedItem().(projectItem); ok {
+				return m.updateProject(projItem, func(p *project.Project) {
+					p.Archived = !p.Archived
+				})
+			}
+		case "v":
+			// Toggle visibility of archived projects
+			m.showArchived = !m.showArchived
+			return m.reload(m.projects), nil
+		case "d", "D":
+			// Ask before deleting selected project, 'D' also removes files
+			if projItem, ok := m.list.SelectedItem().(projectItem); ok {
+				m.pending = projItem
+				m.deleteStep = deleteConfirm
+				if msg.String() == "D" {
+					m.deleteStep = deleteFilesConfirm
 				}
-
-				// Reload the list
-				return initialManageModel(config.Projects), nil
+				return m, nil
 			}
+		case "u":
+			return m.undoDelete()
 		}
 	}
 
@@ -144,21 +543,144 @@ func (m manageProjectsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m manageProjectsModel) View() string {
-	return m.list.View() + "\n\nPress 'a' to add, 'e/enter' to edit, 'd' to delete, 'q' to quit"
+	name := m.pending.project.Name
+	switch m.deleteStep {
+	case deleteConfirm:
+		return m.list.View() + fmt.Sprintf("\n\nDelete '%s'? It can be restored with 'u' or 'asap-pm restore' (y/n)", name)
+	case deleteFilesConfirm:
+		return m.list.View() + fmt.Sprintf("\n\nDelete '%s' AND all files in %s? (y/n)", name, m.pending.project.Path)
+	case deleteFilesFinal:
+		return m.list.View() + fmt.Sprintf("\n\nThis cannot be undone. Really remove %s from disk? (y/n)", m.pending.project.Path)
+	}
+
+	if m.palette != nil {
+		return m.list.View() + "\n\n" + m.palette.View()
+	}
+	if m.worktrees != nil {
+		return m.list.View() + "\n\n" + m.worktrees.View()
+	}
+	if m.details != "" {
+		return m.list.View() + "\n\n" + m.details + "\n\nPress any key to close"
+	}
+
+	archivedHint := "'v' to show archived"
+	if m.showArchived {
+		archivedHint = "'v' to hide archived"
+	}
+	s := m.list.View()
+	if m.status != "" {
+		s += "\n\n" + m.status
+	}
+	return s + "\n\nPress 'a' to add, 'n' to create from a template, 'e/enter' to edit, 'o' to open, 'w' for worktrees, 't' to run a task, 'i' for details, 'd' to delete ('D' with files), 'u' to undo, 'p' to pin, 'x' to archive, " + archivedHint + ", 'q' to quit"
+}
+
+// newListDelegate returns the list item delegate styled with the configured
+// theme color.
+func newListDelegate() list.DefaultDelegate {
+	delegate := list.NewDefaultDelegate()
+
+	settings, err := project.LoadSettings()
+	if err != nil || settings.Theme == "" {
+		return delegate
+	}
+
+	color := lipgloss.Color(settings.Theme)
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(color).BorderLeftForeground(color)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(color).BorderLeftForeground(color)
+	return delegate
 }
 
-func initialManageModel(projects []project.Project) manageProjectsModel {
-	items := make([]list.Item, len(projects))
+func initialManageModel(projects []project.Project, showArchived bool) manageProjectsModel {
+	var missing []string
+	if resp, err := project.QueryDaemon(project.ActiveProfile(), project.DaemonRequest{Method: project.DaemonStatus}); err == nil {
+		missing = resp.Missing
+	}
+
+	items := make([]projectItem, len(projects))
 	for i, p := range projects {
-		items[i] = projectItem{project: p}
+		items[i] = projectItem{project: p, profile: project.ActiveProfile(), index: i, missing: slices.Contains(missing, p.Name)}
 	}
 
-	l := list.New(items, list.NewDefaultDelegate(), 80, 20)
+	m := newManageModel(items, showArchived)
+	m.projects = projects
+	return m
+}
+
+// initialMergedManageModel shows the projects of all profiles in one list.
+// Changes are written back to the profile each project belongs to.
+func initialMergedManageModel(showArchived bool) manageProjectsModel {
+	var items []projectItem
+
+	profiles, _ := project.Profiles()
+	for _, profile := range profiles {
+		config, err := project.LoadProfile(profile)
+		if err != nil {
+			continue
+		}
+		for i, p := range config.Projects {
+			items = append(items, projectItem{project: p, profile: profile, index: i, showProfile: true})
+		}
+	}
+
+	m := newManageModel(items, showArchived)
+	m.merged = true
+	m.list.Title = "Manage Projects (all profiles)"
+	return m
+}
+
+// groupWorktrees moves worktrees right below the project they belong to.
+// Worktrees whose project is not listed stay where they are.
+func groupWorktrees(items []list.Item) []list.Item {
+	key := func(item projectItem, name string) string {
+		return item.profile + "\x00" + name
+	}
+
+	listed := map[string]bool{}
+	for _, item := range items {
+		listed[key(item.(projectItem), item.(projectItem).project.Name)] = true
+	}
+	children := map[string][]list.Item{}
+	var top []list.Item
+	for _, item := range items {
+		pi := item.(projectItem)
+		if parent := key(pi, pi.project.Parent); pi.project.Parent != "" && listed[parent] {
+			pi.nested = true
+			children[parent] = append(children[parent], pi)
+		} else {
+			top = append(top, item)
+		}
+	}
+
+	grouped := make([]list.Item, 0, len(items))
+	for _, item := range top {
+		pi := item.(projectItem)
+		grouped = append(grouped, item)
+		grouped = append(grouped, children[key(pi, pi.project.Name)]...)
+	}
+	return grouped
+}
+
+func newManageModel(projectItems []projectItem, showArchived bool) manageProjectsModel {
+	// Pinned projects go first, archived ones are hidden unless requested
+	var pinned, rest []list.Item
+	for _, item := range projectItems {
+		if item.project.Archived && !showArchived {
+			continue
+		}
+		if item.project.Pinned {
+			pinned = append(pinned, item)
+		} else {
+			rest = append(rest, item)
+		}
+	}
+	items := groupWorktrees(append(pinned, rest...))
+
+	l := list.New(items, newListDelegate(), 80, 20)
 	l.Title = "Manage Projects"
 
 	return manageProjectsModel{
-		list:     l,
-		projects: projects,
+		list:         l,
+		showArchived: showArchived,
 	}
 }
 
@@ -170,11 +692,14 @@ type editProjectModel struct {
 	languages    []string
 	selectedLang int
 	customLang   textinput.Model
-	originalIdx  int
-	useNeovim    bool
+	profile      string
+	originalName string
+	useEditor    bool
+	editor       string
+	err          string
 }
 
-func initialEditModel(proj project.Project, idx int) editProjectModel {
+func initialEditModel(proj project.Project, profile string) editProjectModel {
 	nameInput := textinput.New()
 	nameInput.Placeholder = "Project name"
 	nameInput.SetValue(proj.Name)
@@ -210,14 +735,21 @@ func initialEditModel(proj project.Project, idx int) editProjectModel {
 		customLangInput.SetValue(proj.Language)
 	}
 
+	editor := project.DefaultSettings().Editor
+	if settings, err := project.LoadSettings(); err == nil {
+		editor = settings.Editor
+	}
+
 	m := editProjectModel{
 		inputs:       inputs,
 		cursor:       0,
 		languages:    languages,
 		selectedLang: selectedLang,
 		customLang:   customLangInput,
-		originalIdx:  idx,
-		useNeovim:    false,
+		profile:      profile,
+		originalName: proj.Name,
+		useEditor:    false,
+		editor:       editor,
 	}
 
 	return m
@@ -234,7 +766,7 @@ func (m editProjectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c":
 			return m, tea.Quit
 		case "ctrl+n":
-			m.useNeovim = true
+			m.useEditor = true
 			m.submitted = true
 			return m, tea.Quit
 		case "esc":
@@ -285,6 +817,10 @@ func (m editProjectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.customLang.Focus()
 				}
 			} else {
+				if err := (project.Project{Name: m.inputs[0].Value(), Path: m.inputs[1].Value()}).Validate(); err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
 				m.submitted = true
 				return m, tea.Quit
 			}
@@ -348,8 +884,12 @@ func (m editProjectModel) View() string {
 		}
 	}
 
+	if m.err != "" {
+		s += "\n" + m.err + "\n"
+	}
+
 	s += "\nTab/Shift+Tab to navigate, Up/Down in language selection"
-	s += "\nEnter to save, Ctrl+N to edit in Neovim, Esc to cancel"
+	s += fmt.Sprintf("\nEnter to save, Ctrl+N to edit in %s, Esc to cancel", m.editor)
 	return s
 }
 
@@ -361,6 +901,7 @@ type addProjectModel struct {
 	selectedLang int
 	customLang   textinput.Model
 	editMode     bool
+	err          string
 }
 
 func initialAddModel() addProjectModel {
@@ -429,6 +970,9 @@ func (m addProjectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor = 0 // reset to language
 			}
 
+		case "ctrl+t":
+			return initialNewModel(), nil
+
 		case "esc":
 			if m.editMode {
 				m.editMode = false
@@ -440,6 +984,10 @@ func (m addProjectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "tab", "shift+tab", "enter":
 
 			if msg.String() == "enter" {
+				if err := (project.Project{Name: m.inputs[0].Value(), Path: m.inputs[1].Value()}).Validate(); err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
 				m.submitted = true
 				return m, tea.Quit
 			}
@@ -555,14 +1103,38 @@ func (m addProjectModel) View() string {
 		}
 	}
 
+	if m.err != "" {
+		s += "\n" + m.err + "\n"
+	}
+
 	if !m.editMode {
 		s += "\nPress 'Ctrl+E' to edit name/path, "
 	}
+	s += "'Ctrl+T' to create from a template, "
 	s += "Enter to submit, Esc to quit"
 	return s
 }
 
-func openInNeovim(proj project.Project) (project.Project, error) {
+// resolvePath makes a user-entered path absolute: "~" and environment
+// variables are expanded and relative paths are taken from the base_dir
+// setting, which defaults to the home directory.
+func resolvePath(path string) string {
+	// Variables are expanded once, a "$" in their values stays literal
+	path = project.ExpandEnv(path)
+	if !filepath.IsAbs(path) && !strings.HasPrefix(path, "~") {
+		if settings, err := project.LoadSettings(); err == nil {
+			path = filepath.Join(project.ExpandPath(settings.BaseDir), path)
+		}
+	}
+	return filepath.Clean(project.ExpandHome(path))
+}
+
+func openInEditor(proj project.Project) (project.Project, error) {
+	settings, err := project.LoadSettings()
+	if err != nil {
+		return proj, err
+	}
+
 	// Create a temporary file with project data
 	tmpFile, err := os.CreateTemp("", "project-*.txt")
 	if err != nil {
@@ -582,8 +1154,12 @@ func openInNeovim(proj project.Project) (project.Project, error) {
 		return proj, err
 	}
 
-	// Open in neovim
-	cmd := exec.Command("nvim", tmpFile.Name())
+	// Open in the configured editor, which may carry its own arguments
+	editor := strings.Fields(settings.Editor)
+	if len(editor) == 0 {
+		return proj, fmt.Errorf("no editor configured")
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], tmpFile.Name())...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -621,7 +1197,35 @@ func openInNeovim(proj project.Project) (project.Project, error) {
 
 func main() {
 
-	if len(os.Args) == 1 {
+	// Global options come before the subcommand
+	global := flag.NewFlagSet("asap-pm", flag.ExitOnError)
+	profile := global.String("profile", "", "profile to use (default: $ASAP_PM_PROFILE or \"default\")")
+	allProfiles := global.Bool("all-profiles", false, "show the projects of every profile in the TUI")
+	noCache := global.Bool("no-cache", false, "read projects and detect languages without the on-disk cache")
+	_ = global.Parse(os.Args[1:])
+	args := global.Args()
+	project.UseProfile(*profile)
+	project.UseCache(!*noCache)
+	defer project.SaveCaches()
+
+	// Subcommands
+	if len(args) > 0 {
+		if run, ok := commands[args[0]]; ok {
+			err := run(args[1:])
+			project.SaveCaches()
+			var code exitCode
+			if errors.As(err, &code) {
+				os.Exit(int(code))
+			}
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	if len(args) == 0 {
 
 		// Get current working directory
 		cwd, err := os.Getwd()
@@ -630,26 +1234,27 @@ func main() {
 			os.Exit(1)
 		}
 
-		// Load config
+		// Load config, letting the user fix a broken file
 		config, err := project.LoadConfig()
-		if err != nil {
-			fmt.Println("Error loading config:", err)
-			os.Exit(1)
+		if err == nil {
+			err = config.Problems()
 		}
-
-		// Check if current directory is already a project
-		isProject := false
-		for _, p := range config.Projects {
-			if p.Path == cwd {
-				isProject = true
-				break
+		if err != nil {
+			if config = recoverConfig(err); config == nil {
+				fmt.Println("Error loading config:", err)
+				os.Exit(1)
 			}
 		}
 
+		// Check if current directory belongs to a project
+		current, isProject := project.FindCurrent(project.ActiveProfile(), config, cwd)
+
 		var initialModel tea.Model
-		if isProject {
-			// Launch manage projects TUI
-			initialModel = initialManageModel(config.Projects)
+		if *allProfiles {
+			initialModel = initialMergedManageModel(false).selectProject(project.ActiveProfile(), current)
+		} else if isProject {
+			// Launch manage projects TUI on the current project
+			initialModel = initialManageModel(config.Projects, false).selectProject(project.ActiveProfile(), current)
 		} else {
 			// Launch add project TUI
 			initialModel = initialAddModel()
@@ -678,18 +1283,8 @@ func main() {
 				os.Exit(1)
 			}
 
-			// Load config
-			config, err := project.LoadConfig()
-			if err != nil {
-				fmt.Println("Error loading config:", err)
-				os.Exit(1)
-			}
-
 			// Resolve path
-			home, _ := os.UserHomeDir()
-			if !filepath.IsAbs(path) {
-				path = filepath.Join(home, path)
-			}
+			path = resolvePath(path)
 
 			newProject := project.Project{
 				Name:     name,
@@ -697,44 +1292,45 @@ func main() {
 				Language: language,
 			}
 
-			config.Projects = append(config.Projects, newProject)
-			if err := project.SaveConfig(config); err != nil {
-				fmt.Println("Error saving config:", err)
+			if err := addProject(newProject); err != nil {
+				fmt.Println("Error:", err)
 				os.Exit(1)
 			}
 
-			fmt.Println("Project added successfully!")
-
-			// Launch Java project structure detector if it's a Java project
-			if language == "java" {
-				fmt.Println("Detecting Java project structure...")
-				if err := runJavaStructureDetector(path); err != nil {
-					fmt.Printf("Warning: Failed to run Java project structure detector: %v\n", err)
-				} else {
-					fmt.Println("Java project structure detected and saved.")
-				}
+		} else if newModel, ok := m.(newProjectModel); ok && newModel.submitted {
+			name := newModel.inputs[0].Value()
+			if err := createProject(name, resolvePath(newModel.path()), newModel.templates[newModel.selected], nil, true); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
 			}
 
 		} else if editModel, ok := m.(editProjectModel); ok && editModel.submitted {
-			// Load config
-			config, err := project.LoadConfig()
+			// Load config of the profile the project belongs to. The lock
+			// is only taken to save, not while the editor is open.
+			config, err := project.LoadProfile(editModel.profile)
 			if err != nil {
 				fmt.Println("Error loading config:", err)
 				os.Exit(1)
 			}
+			originalIdx, ok := config.Find(editModel.originalName)
+			if !ok {
+				fmt.Printf("Error: project %q no longer exists\n", editModel.originalName)
+				os.Exit(1)
+			}
 
 			var updatedProject project.Project
 
-			if editModel.useNeovim {
+			if editModel.useEditor {
 				// Get the original project
-				originalProject := config.Projects[editModel.originalIdx]
+				originalProject := config.Projects[originalIdx]
 
-				// Open in neovim
-				updatedProject, err = openInNeovim(originalProject)
+				// Open in editor
+				updatedProject, err = openInEditor(originalProject)
 				if err != nil {
-					fmt.Println("Error opening neovim:", err)
+					fmt.Println("Error opening editor:", err)
 					os.Exit(1)
 				}
+				updatedProject.Path = resolvePath(updatedProject.Path)
 			} else {
 				// Get values from the TUI
 				name := editModel.inputs[0].Value()
@@ -752,27 +1348,28 @@ func main() {
 				}
 
 				// Resolve path
-				home, _ := os.UserHomeDir()
-				if !filepath.IsAbs(path) {
-					path = filepath.Join(home, path)
-				}
+				path = resolvePath(path)
 
-				updatedProject = project.Project{
-					Name:     name,
-					Path:     path,
-					Language: language,
-				}
+				updatedProject = config.Projects[originalIdx]
+				updatedProject.Name = name
+				updatedProject.Path = path
+				updatedProject.Language = language
 			}
 
-			// Update the project in config
-			config.Projects[editModel.originalIdx] = updatedProject
-
-			if err := project.SaveConfig(config); err != nil {
+			// Update the project in a fresh copy of the config, worktrees
+			// follow a rename
+			err = project.UpdateProfile(editModel.profile, func(config *project.Config) error {
+				return applyUpdate(config, editModel.originalName, updatedProject)
+			})
+			if err != nil {
 				fmt.Println("Error saving config:", err)
 				os.Exit(1)
 			}
 
 			fmt.Println("Project updated successfully!")
+			if err := project.RunHooks(os.Stdout, project.HookEdit, editModel.profile, updatedProject); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
 
 		} else if editModel, ok := m.(editProjectModel); ok && editModel.cancelled {
 			// User cancelled, return to manage view
@@ -782,7 +1379,11 @@ func main() {
 				os.Exit(1)
 			}
 
-			p := tea.NewProgram(initialManageModel(config.Projects))
+			manageModel := initialManageModel(config.Projects, false)
+			if *allProfiles {
+				manageModel = initialMergedManageModel(false)
+			}
+			p := tea.NewProgram(manageModel)
 			_, err = p.Run()
 			if err != nil {
 				fmt.Println("Error:", err)
@@ -793,32 +1394,22 @@ func main() {
 	} else {
 
 		// CLI add
-		if len(os.Args) < 3 {
+		if len(args) < 2 {
 			fmt.Println("Usage: asap-pm <name> <path> [language]")
 			os.Exit(1)
 		}
 
-		name := os.Args[1]
-		path := os.Args[2]
+		name := args[0]
+		path := args[1]
 
 		language := ""
 
-		if len(os.Args) > 3 {
-			language = os.Args[3]
-		}
-
-		// Load config
-		config, err := project.LoadConfig()
-		if err != nil {
-			fmt.Println("Error loading config:", err)
-			os.Exit(1)
+		if len(args) > 2 {
+			language = args[2]
 		}
 
 		// Resolve path
-		home, _ := os.UserHomeDir()
-		if !filepath.IsAbs(path) {
-			path = filepath.Join(home, path)
-		}
+		path = resolvePath(path)
 
 		// Guess language if not provided
 		if language == "" {
@@ -838,25 +1429,11 @@ func main() {
 			Language: language,
 		}
 
-		config.Projects = append(config.Projects, newProject)
-
-		if err := project.SaveConfig(config); err != nil {
-			fmt.Println("Error saving config:", err)
+		if err := addProject(newProject); err != nil {
+			fmt.Println("Error:", err)
 			os.Exit(1)
 		}
 
-		fmt.Println("Project added successfully!")
-
-		// Launch Java project structure detector if it's a Java project
-		if language == "java" {
-			fmt.Println("Detecting Java project structure...")
-			if err := runJavaStructureDetector(path); err != nil {
-				fmt.Printf("Warning: Failed to run Java project structure detector: %v\n", err)
-			} else {
-				fmt.Println("Java project structure detected and saved.")
-			}
-		}
-
 	}
 
 }
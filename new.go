@@ -0,0 +1,263 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// isGitURL reports whether spec names a repository rather than a directory.
+func isGitURL(spec string) bool {
+	return strings.Contains(spec, "://") || strings.HasPrefix(spec, "git@") || strings.HasSuffix(spec, ".git")
+}
+
+// loadTemplate resolves spec to a template: a git URL is cloned to a
+// temporary directory, a path is used as is and anything else is looked up
+// in the templates directory. The returned function removes the clone.
+func loadTemplate(spec string) (*project.Template, func(), error) {
+	cleanup := func() {}
+
+	if isGitURL(spec) {
+		dir, err := os.MkdirTemp("", "asap-pm-template-")
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		cleanup = func() { _ = os.RemoveAll(dir) }
+
+		cmd := exec.Command("git", "clone", "--quiet", "--depth", "1", "--", spec, dir)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return nil, cleanup, fmt.Errorf("failed to clone template %s: %w", spec, err)
+		}
+		t, err := project.LoadTemplate(dir)
+		return t, cleanup, err
+	}
+
+	dir := project.ExpandPath(spec)
+	if !strings.ContainsRune(spec, filepath.Separator) && !strings.HasPrefix(spec, ".") {
+		templates, err := project.TemplatesDir()
+		if err != nil {
+			return nil, cleanup, err
+		}
+		dir = filepath.Join(templates, spec)
+	}
+	t, err := project.LoadTemplate(dir)
+	return t, cleanup, err
+}
+
+// createProject renders the template into path, runs its hooks and
+// registers the result.
+func createProject(name, path, spec string, vars map[string]string, hooks bool) error {
+	// Nothing is written for a project that cannot be registered
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := config.Find(name); ok {
+		return fmt.Errorf("project %q already exists", name)
+	}
+	for _, p := range config.Projects {
+		if p.Path == path {
+			return fmt.Errorf("%s is already registered as %q", path, p.Name)
+		}
+	}
+
+	t, cleanup, err := loadTemplate(spec)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	data, err := t.Data(name, path, vars)
+	if err != nil {
+		return err
+	}
+	if err := t.Render(path, data); err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	fmt.Printf("Created %s from template %s\n", path, spec)
+
+	if hooks {
+		commands, err := t.HookCommands(data)
+		if err != nil {
+			return err
+		}
+		for _, command := range commands {
+			fmt.Println("$", command)
+			cmd := exec.Command("sh", "-c", command)
+			cmd.Dir = path
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				fmt.Printf("Warning: hook %q failed: %v\n", command, err)
+			}
+		}
+	}
+
+	language := t.Language
+	if language == "" {
+		language = "other"
+		if languages := project.GuessLanguage(path); len(languages) > 0 {
+			language = languages[0]
+		}
+	}
+
+	return addProject(project.Project{Name: name, Path: path, Language: language})
+}
+
+// varFlags collects repeated --var key=value flags.
+type varFlags map[string]string
+
+func (v varFlags) String() string {
+	return ""
+}
+
+func (v varFlags) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	v[key] = value
+	return nil
+}
+
+func runNew(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	tmpl := fs.String("template", "", "template name, directory or git URL")
+	path := fs.String("path", "", "where to create the project (default: <base_dir>/<name>)")
+	noHooks := fs.Bool("no-hooks", false, "do not run the template's post-create hooks")
+	vars := varFlags{}
+	fs.Var(vars, "var", "template variable as key=value, may be repeated")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || *tmpl == "" {
+		return fmt.Errorf("usage: asap-pm new <name> --template <template> [--path <path>] [--var key=value] [--no-hooks]")
+	}
+
+	name := args[0]
+	if *path == "" {
+		*path = name
+	}
+
+	if err := (project.Project{Name: name, Path: *path}).Validate(); err != nil {
+		return err
+	}
+	return createProject(name, resolvePath(*path), *tmpl, vars, !*noHooks)
+}
+
+// newProjectModel picks a template and a name for a project created by
+// "asap-pm new".
+type newProjectModel struct {
+	templates []string
+	selected  int
+	inputs    []textinput.Model // name, path
+	cursor    int
+	submitted bool
+	err       string
+}
+
+func initialNewModel() newProjectModel {
+	templates, err := project.ListTemplates()
+
+	nameInput := textinput.New()
+	nameInput.Placeholder = "Project name"
+	nameInput.Focus()
+
+	pathInput := textinput.New()
+	pathInput.Placeholder = "Project path (default: <base_dir>/<name>)"
+
+	m := newProjectModel{
+		templates: templates,
+		inputs:    []textinput.Model{nameInput, pathInput},
+	}
+	if err != nil {
+		m.err = err.Error()
+	} else if len(templates) == 0 {
+		dir, _ := project.TemplatesDir()
+		m.err = fmt.Sprintf("No templates found in %s", dir)
+	}
+	return m
+}
+
+// path returns the entered path, defaulting to the project name.
+func (m newProjectModel) path() string {
+	if path := m.inputs[1].Value(); path != "" {
+		return path
+	}
+	return m.inputs[0].Value()
+}
+
+func (m newProjectModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m newProjectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "up":
+			if len(m.templates) > 0 {
+				m.selected = (m.selected - 1 + len(m.templates)) % len(m.templates)
+			}
+			return m, nil
+		case "down":
+			if len(m.templates) > 0 {
+				m.selected = (m.selected + 1) % len(m.templates)
+			}
+			return m, nil
+		case "tab", "shift+tab":
+			m.inputs[m.cursor].Blur()
+			m.cursor = (m.cursor + 1) % len(m.inputs)
+			return m, m.inputs[m.cursor].Focus()
+		case "enter":
+			if len(m.templates) == 0 {
+				return m, nil
+			}
+			if err := (project.Project{Name: m.inputs[0].Value(), Path: m.path()}).Validate(); err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			m.submitted = true
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.cursor], cmd = m.inputs[m.cursor].Update(msg)
+	return m, cmd
+}
+
+func (m newProjectModel) View() string {
+	s := "Create a project from a template\n\n"
+
+	for i, t := range m.templates {
+		cursor := " "
+		if i == m.selected {
+			cursor = ">"
+		}
+		s += fmt.Sprintf("%s %s\n", cursor, t)
+	}
+
+	s += "\n"
+	for _, input := range m.inputs {
+		s += input.View() + "\n"
+	}
+
+	if m.err != "" {
+		s += "\n" + m.err + "\n"
+	}
+
+	s += "\nUp/Down to pick a template, Tab to switch fields, Enter to create, Esc to quit"
+	return s
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// nvimProject is one entry of "asap-pm nvim-sessions --json", shaped for
+// Telescope and fzf-lua pickers.
+type nvimProject struct {
+	Name        string           `json:"name"`
+	Path        string           `json:"path"`
+	Language    string           `json:"language,omitempty"`
+	Session     string           `json:"session"`
+	HasSession  bool             `json:"has_session"`
+	RootMarkers []string         `json:"root_markers,omitempty"`
+	Modules     []project.Module `json:"modules,omitempty"` // from .asap/project.toml
+}
+
+func runNvimSessions(args []string) error {
+	fs := flag.NewFlagSet("nvim-sessions", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print projects with sessions, root markers and modules as JSON")
+	all := fs.Bool("all", false, "include archived projects")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	// session_dir is global, a broken .asap/config.toml changes nothing here
+	settings, err := project.LoadSettings()
+	if err != nil {
+		return err
+	}
+	projects := config.Active()
+	if *all {
+		projects = config.Projects
+	}
+
+	entries := []nvimProject{}
+	for _, p := range projects {
+		session, ok := settings.Session(p)
+		entry := nvimProject{
+			Name:       p.Name,
+			Path:       p.Path,
+			Language:   p.Language,
+			Session:    session,
+			HasSession: ok,
+		}
+
+		if *asJSON {
+			entry.RootMarkers = project.RootMarkers(p.Path)
+			if structure, err := project.LoadStructure(p.Path); err == nil {
+				entry.Modules = structure.Modules
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if *asJSON {
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// Tab separated for fzf --delimiter '\t'
+	for _, e := range entries {
+		fmt.Printf("%s\t%s\n", e.Name, e.Session)
+	}
+	return nil
+}
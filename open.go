@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// launchCmd builds the command opening p with the named launcher, or with
+// the default one when launcher is empty. It runs in the project directory
+// with the project environment.
+func launchCmd(p project.Project, launcher string) (*exec.Cmd, error) {
+	settings, err := project.LoadProjectSettings(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	command, err := settings.LaunchCommand(launcher, p)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := project.Environ(p)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = p.Path
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd, nil
+}
+
+func runOpen(args []string) error {
+	fs := flag.NewFlagSet("open", flag.ExitOnError)
+	with := fs.String("with", "", "launcher to use (default: launcher setting)")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: asap-pm open <name> [--with <launcher>]")
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	idx, ok := config.Find(args[0])
+	if !ok {
+		return fmt.Errorf("project %q not found", args[0])
+	}
+
+	cmd, err := launchCmd(config.Projects[idx], *with)
+	if err != nil {
+		return err
+	}
+	runHooks(project.HookOpen, config.Projects[idx])
+	return cmd.Run()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+const profileUsage = `usage:
+  asap-pm profile list
+  asap-pm [--profile <from>] profile mv <project> <to-profile>`
+
+func runProfile(args []string) error {
+	if len(args) == 0 {
+		return errors.New(profileUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		profiles, err := project.Profiles()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, profile := range profiles {
+			config, err := project.LoadProfile(profile)
+			if err != nil {
+				return err
+			}
+			mark := " "
+			if profile == project.ActiveProfile() {
+				mark = "*"
+			}
+			_, _ = fmt.Fprintf(w, "%s %s\t%d project(s)\n", mark, profile, len(config.Projects))
+		}
+		return w.Flush()
+
+	case "mv":
+		if len(args) != 3 {
+			return errors.New(profileUsage)
+		}
+		name, to := args[1], args[2]
+		from := project.ActiveProfile()
+		if from == to {
+			return fmt.Errorf("project %q is already in profile %q", name, to)
+		}
+
+		// Both profiles are locked in the same order by everyone
+		for _, profile := range slices.Sorted(slices.Values([]string{from, to})) {
+			unlock, err := project.LockProfile(profile)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+		}
+
+		source, err := project.LoadProfile(from)
+		if err != nil {
+			return err
+		}
+		target, err := project.LoadProfile(to)
+		if err != nil {
+			return err
+		}
+
+		idx, ok := source.Find(name)
+		if !ok {
+			return fmt.Errorf("project %q not found in profile %q", name, from)
+		}
+
+		// Worktrees go along with their project, a worktree moved on its
+		// own leaves its project behind
+		moved := []project.Project{source.Projects[idx]}
+		moved[0].Parent = ""
+		for _, p := range source.Projects {
+			if p.Parent == name {
+				moved = append(moved, p)
+			}
+		}
+		for _, p := range moved {
+			if _, exists := target.Find(p.Name); exists {
+				return fmt.Errorf("profile %q already has a project named %q", to, p.Name)
+			}
+		}
+
+		// Write the target first so a failure never loses the project
+		target.Projects = append(target.Projects, moved...)
+		if err := project.SaveProfile(to, target); err != nil {
+			return fmt.Errorf("failed to save profile %q: %w", to, err)
+		}
+		source.Projects = slices.DeleteFunc(source.Projects, func(p project.Project) bool {
+			return p.Name == name || p.Parent == name
+		})
+		if err := project.SaveProfile(from, source); err != nil {
+			return fmt.Errorf("failed to save profile %q: %w", from, err)
+		}
+
+		for _, p := range moved {
+			fmt.Printf("Moved %s from %s to %s\n", p.Name, from, to)
+		}
+		return nil
+	}
+
+	return errors.New(profileUsage)
+}
@@ -0,0 +1,192 @@
+package project
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// cacheEnabled is cleared by --no-cache. Without it every lookup goes to
+// the files themselves.
+var cacheEnabled = true
+
+// UseCache turns the on-disk caches of parsed configs, the prompt index and
+// language detection on or off.
+func UseCache(enabled bool) {
+	cacheEnabled = enabled
+}
+
+// CacheDir returns the directory holding the caches, which can be removed
+// at any time.
+func CacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "asap-project-manager"), nil
+}
+
+func cacheFile(name string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// readCache decodes the gob file name into v.
+func readCache(name string, v any) error {
+	filePath, err := cacheFile(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// writeCache stores v as the gob file name. The file is replaced atomically
+// so concurrent readers never see half of it.
+func writeCache(name string, v any) error {
+	filePath, err := cacheFile(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".cache-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := gob.NewEncoder(tmp).Encode(v); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// configCache is a validated, migrated projects file before path expansion.
+type configCache struct {
+	ModTime int64
+	Size    int64
+	Hash    [sha256.Size]byte
+	Config  Config
+}
+
+func configCacheName(profile string) string {
+	return "config-" + profile + ".gob"
+}
+
+// cachedConfig returns the parsed content of filePath, the projects file of
+// profile with info, if the cache still matches it. A changed modification
+// time alone does not invalidate the cache while the content hashes the same.
+func cachedConfig(profile, filePath string, info os.FileInfo) (*Config, bool) {
+	if !cacheEnabled || info == nil {
+		return nil, false
+	}
+	var cache configCache
+	if err := readCache(configCacheName(profile), &cache); err != nil {
+		return nil, false
+	}
+
+	if cache.ModTime != info.ModTime().UnixNano() || cache.Size != info.Size() {
+		data, err := os.ReadFile(filePath)
+		if err != nil || sha256.Sum256(data) != cache.Hash {
+			return nil, false
+		}
+		cache.ModTime, cache.Size = info.ModTime().UnixNano(), info.Size()
+		_ = writeCache(configCacheName(profile), &cache)
+	}
+
+	config := cache.Config
+	if config.Projects == nil {
+		config.Projects = []Project{}
+	}
+	return &config, true
+}
+
+// storeConfigCache caches config, freshly decoded from a file that had info
+// before it was read. Configs with keys unknown to this build are not
+// cached, gob would drop them.
+func storeConfigCache(profile string, info os.FileInfo, config *Config) {
+	if !cacheEnabled || info == nil || config.hasExtra() {
+		return
+	}
+
+	cache := configCache{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Hash:    sha256.Sum256([]byte(strings.Join(config.source, "\n"))),
+		Config:  *config,
+	}
+	_ = writeCache(configCacheName(profile), &cache)
+}
+
+// detection is a cached GuessLanguage result. Markers are looked up in the
+// project root only, so its modification time tells when to detect again.
+type detection struct {
+	ModTime   int64
+	Languages []string
+}
+
+const detectionCacheName = "detections.gob"
+
+var detections struct {
+	loaded  bool
+	changed bool
+	entries map[string]detection
+}
+
+func cachedDetection(path string, info os.FileInfo) ([]string, bool) {
+	if !cacheEnabled {
+		return nil, false
+	}
+	if !detections.loaded {
+		detections.loaded = true
+		if readCache(detectionCacheName, &detections.entries) != nil {
+			detections.entries = map[string]detection{}
+		}
+	}
+	d, ok := detections.entries[path]
+	if !ok || d.ModTime != info.ModTime().UnixNano() {
+		return nil, false
+	}
+	return slices.Clone(d.Languages), true
+}
+
+func storeDetection(path string, info os.FileInfo, languages []string) {
+	if !cacheEnabled || detections.entries == nil {
+		return
+	}
+	detections.entries[path] = detection{info.ModTime().UnixNano(), slices.Clone(languages)}
+	detections.changed = true
+}
+
+// SaveCaches writes caches filled during this run, such as language
+// detection results. Detections of directories that are gone are dropped.
+// Failures only make the next run slower.
+func SaveCaches() {
+	if detections.changed {
+		for path := range detections.entries {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				delete(detections.entries, path)
+			}
+		}
+		_ = writeCache(detectionCacheName, detections.entries)
+		detections.changed = false
+	}
+}
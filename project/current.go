@@ -0,0 +1,39 @@
+package project
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// caseInsensitiveFS is set where the default filesystem ignores case, so
+// "~/Src/API" and "~/src/api" name the same project.
+var caseInsensitiveFS = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// canonicalPath resolves symlinks and, where paths ignore case, folds it.
+// Paths that do not exist are only cleaned.
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	path = filepath.Clean(path)
+	if caseInsensitiveFS {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// FindCurrent returns the index in config of the project dir belongs to,
+// found through the cached index of profile so that paths are not resolved
+// again on every call. Archived projects are not matched.
+func FindCurrent(profile string, config *Config, dir string) (int, bool) {
+	index, err := LoadIndex(profile)
+	if err != nil {
+		return -1, false
+	}
+	entry, ok := index.Lookup(dir)
+	if !ok {
+		return -1, false
+	}
+	return config.Find(entry.Name)
+}
@@ -0,0 +1,80 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Requests understood by the daemon, one JSON object per line.
+const (
+	DaemonLookup = "lookup" // project and module containing Dir
+	DaemonStatus = "status" // projects whose directory is gone
+)
+
+type DaemonRequest struct {
+	Method string `json:"method"`
+	Dir    string `json:"dir,omitempty"`
+}
+
+type DaemonResponse struct {
+	Error    string   `json:"error,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Language string   `json:"language,omitempty"`
+	Module   string   `json:"module,omitempty"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// daemonTimeout bounds a query, a prompt must not hang on a stuck daemon.
+const daemonTimeout = 200 * time.Millisecond
+
+// DaemonSocket returns the socket the daemon of profile listens on, in
+// $XDG_RUNTIME_DIR when set and in the cache directory otherwise.
+func DaemonSocket(profile string) (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		cacheDir, err := CacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = cacheDir
+	}
+	return filepath.Join(dir, "asap-pm-"+profile+".sock"), nil
+}
+
+// QueryDaemon sends req to the daemon of profile. It fails quickly when no
+// daemon is running, callers then answer the query themselves.
+func QueryDaemon(profile string, req DaemonRequest) (*DaemonResponse, error) {
+	socket, err := DaemonSocket(profile)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", socket, daemonTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(daemonTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to query daemon: %w", err)
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daemon response: %w", err)
+	}
+	var resp DaemonResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid daemon response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,132 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+type IssueKind int
+
+const (
+	IssueMissingPath IssueKind = iota
+	IssueDuplicate
+	IssueNotGitRepo
+	IssueStaleStructure
+	IssueLanguageChanged
+	IssueInvalid
+)
+
+func (k IssueKind) String() string {
+	switch k {
+	case IssueMissingPath:
+		return "missing path"
+	case IssueDuplicate:
+		return "duplicate"
+	case IssueNotGitRepo:
+		return "not a git repository"
+	case IssueStaleStructure:
+		return "stale structure"
+	case IssueLanguageChanged:
+		return "language not detected"
+	case IssueInvalid:
+		return "invalid entry"
+	}
+	return "unknown"
+}
+
+// Issue is a problem found with the project at Index in Config.Projects.
+type Issue struct {
+	Index  int
+	Kind   IssueKind
+	Detail string
+}
+
+// structureInputs are the build files whose changes make .asap/project.toml
+// out of date.
+var structureInputs = []string{
+	"pom.xml",
+	"build.gradle",
+	"build.gradle.kts",
+	"settings.gradle",
+	"settings.gradle.kts",
+}
+
+// Diagnose checks every active project for problems. Archived projects are
+// only checked for duplicate names, which cannot be saved.
+func Diagnose(c *Config) []Issue {
+	var issues []Issue
+	seenPaths := map[string]int{}
+	seenNames := map[string]int{}
+
+	for i, p := range c.Projects {
+		if problem, ok := c.invalid[i]; ok {
+			issues = append(issues, Issue{i, IssueInvalid, problem})
+			continue
+		}
+		firstName, sameName := seenNames[p.Name]
+		if !sameName {
+			seenNames[p.Name] = i
+		}
+		if p.Archived {
+			if sameName {
+				issues = append(issues, Issue{i, IssueDuplicate, fmt.Sprintf("same name as project at %s", c.Projects[firstName].Path)})
+			}
+			continue
+		}
+
+		if first, ok := seenPaths[p.Path]; ok {
+			issues = append(issues, Issue{i, IssueDuplicate, fmt.Sprintf("same path as %q", c.Projects[first].Name)})
+		} else if sameName {
+			issues = append(issues, Issue{i, IssueDuplicate, fmt.Sprintf("same name as project at %s", c.Projects[firstName].Path)})
+		}
+		seenPaths[p.Path] = i
+
+		if _, err := os.Stat(p.Path); err != nil {
+			issues = append(issues, Issue{i, IssueMissingPath, p.Path})
+			continue
+		}
+
+		if !IsGitRepo(p.Path) {
+			issues = append(issues, Issue{i, IssueNotGitRepo, p.Path})
+		}
+
+		if detail, stale := structureStale(p.Path); stale {
+			issues = append(issues, Issue{i, IssueStaleStructure, detail})
+		}
+
+		if KnownLanguage(p.Language) {
+			if detected := GuessLanguage(p.Path); !slices.Contains(detected, p.Language) {
+				issues = append(issues, Issue{i, IssueLanguageChanged, fmt.Sprintf("%s, detected %v", p.Language, detected)})
+			}
+		}
+	}
+
+	return issues
+}
+
+// structureStale reports whether .asap/project.toml describes a different
+// root or is older than one of the build files it was generated from.
+func structureStale(projectPath string) (string, bool) {
+	info, err := os.Stat(StructurePath(projectPath))
+	if err != nil {
+		return "", false
+	}
+
+	structure, err := LoadStructure(projectPath)
+	if err != nil {
+		return err.Error(), true
+	}
+	if structure.Project.Root != "" && filepath.Clean(structure.Project.Root) != filepath.Clean(projectPath) {
+		return fmt.Sprintf("generated for %s", structure.Project.Root), true
+	}
+
+	for _, name := range structureInputs {
+		input, err := os.Stat(filepath.Join(projectPath, name))
+		if err == nil && input.ModTime().After(info.ModTime()) {
+			return fmt.Sprintf("%s changed since last detection", name), true
+		}
+	}
+	return "", false
+}
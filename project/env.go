@@ -0,0 +1,276 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Toolchain is a tool version a project expects.
+type Toolchain struct {
+	Tool    string
+	Version string
+	Source  string // file the pin comes from, or "projects.toml"
+	Dir     string // install directory found for it, empty if not installed
+}
+
+// envName matches the variable names a shell accepts. Anything else would
+// break, or be run by, the export lines printed for eval.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidEnvName reports whether name can be exported by a shell.
+func ValidEnvName(name string) bool {
+	return envName.MatchString(name)
+}
+
+// toolAliases maps the plugin names asdf and mise use to one name.
+var toolAliases = map[string]string{
+	"golang": "go",
+	"nodejs": "node",
+}
+
+func canonicalTool(tool string) string {
+	if alias, ok := toolAliases[tool]; ok {
+		return alias
+	}
+	return tool
+}
+
+// toolVersions reads an asdf .tool-versions file; the first version listed
+// for a tool is the one used.
+func toolVersions(filePath string) map[string]string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	pins := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			pins[canonicalTool(fields[0])] = fields[1]
+		}
+	}
+	return pins
+}
+
+// miseTools reads the [tools] table of a mise config file. Versions may be
+// strings, lists of strings or tables with a version key.
+func miseTools(filePath string) map[string]string {
+	var config struct {
+		Tools map[string]any `toml:"tools"`
+	}
+	if _, err := toml.DecodeFile(filePath, &config); err != nil {
+		return nil
+	}
+
+	pins := map[string]string{}
+	for tool, value := range config.Tools {
+		switch v := value.(type) {
+		case string:
+			pins[canonicalTool(tool)] = v
+		case []any:
+			if len(v) > 0 {
+				pins[canonicalTool(tool)] = fmt.Sprint(v[0])
+			}
+		case map[string]any:
+			if version, ok := v["version"].(string); ok {
+				pins[canonicalTool(tool)] = version
+			}
+		}
+	}
+	return pins
+}
+
+// Toolchains returns the tool versions p expects, sorted by tool. Pins in
+// projects.toml win over mise files, which win over .tool-versions.
+func Toolchains(p Project) []Toolchain {
+	sources := map[string]string{}
+	pins := map[string]string{}
+	add := func(source string, found map[string]string) {
+		for tool, version := range found {
+			pins[tool] = version
+			sources[tool] = source
+		}
+	}
+
+	add(".tool-versions", toolVersions(filepath.Join(p.Path, ".tool-versions")))
+	for _, name := range []string{".mise.toml", "mise.toml", ".config/mise.toml"} {
+		add(name, miseTools(filepath.Join(p.Path, name)))
+	}
+	explicit := map[string]string{}
+	for tool, version := range p.Toolchains {
+		explicit[canonicalTool(tool)] = version
+	}
+	add("projects.toml", explicit)
+
+	var toolchains []Toolchain
+	for _, tool := range slices.Sorted(maps.Keys(pins)) {
+		toolchains = append(toolchains, Toolchain{
+			Tool:    tool,
+			Version: pins[tool],
+			Source:  sources[tool],
+			Dir:     installDir(tool, pins[tool]),
+		})
+	}
+	return toolchains
+}
+
+// installDir looks for an installed version of tool in the mise and asdf
+// data directories.
+func installDir(tool, version string) string {
+	home, _ := os.UserHomeDir()
+	roots := []string{
+		os.Getenv("MISE_DATA_DIR"),
+		filepath.Join(home, ".local", "share", "mise"),
+		os.Getenv("ASDF_DATA_DIR"),
+		filepath.Join(home, ".asdf"),
+	}
+	names := []string{tool}
+	for alias, name := range toolAliases {
+		if name == tool {
+			names = append(names, alias)
+		}
+	}
+
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		for _, name := range names {
+			dir := filepath.Join(root, "installs", name, version)
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				return dir
+			}
+		}
+	}
+	return ""
+}
+
+// binDir returns the directory of an installed toolchain holding its
+// executables. asdf installs Go one level deeper.
+func (t Toolchain) binDir() string {
+	for _, dir := range []string{filepath.Join(t.Dir, "bin"), filepath.Join(t.Dir, "go", "bin")} {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
+
+// readEnvFile parses a dotenv file: KEY=VALUE lines, optionally prefixed
+// with "export", with single or double quoted values and # comments.
+func readEnvFile(filePath string) ([][2]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var vars [][2]string
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", filePath, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		key = strings.TrimSpace(key)
+		if !ValidEnvName(key) {
+			return nil, fmt.Errorf("%s:%d: invalid variable name %q", filePath, n, key)
+		}
+		vars = append(vars, [2]string{key, value})
+	}
+	return vars, scanner.Err()
+}
+
+// ProjectEnv returns the variables p sets, in the order they apply: the env
+// file, the env table and the PATH and home variables of installed
+// toolchains. Values may refer to variables set before them.
+func ProjectEnv(p Project) ([][2]string, error) {
+	var vars [][2]string
+	lookup := func(key string) string {
+		for i := len(vars) - 1; i >= 0; i-- {
+			if vars[i][0] == key {
+				return vars[i][1]
+			}
+		}
+		return os.Getenv(key)
+	}
+
+	if p.EnvFile != "" {
+		envFile := ExpandPath(p.EnvFile)
+		if !filepath.IsAbs(ExpandEnv(p.EnvFile)) && !strings.HasPrefix(p.EnvFile, "~") {
+			envFile = filepath.Join(p.Path, p.EnvFile)
+		}
+		fileVars, err := readEnvFile(envFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range fileVars {
+			vars = append(vars, [2]string{v[0], os.Expand(v[1], lookup)})
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(p.Env)) {
+		if !ValidEnvName(key) {
+			return nil, fmt.Errorf("invalid variable name %q in env of %s", key, p.Name)
+		}
+		vars = append(vars, [2]string{key, os.Expand(p.Env[key], lookup)})
+	}
+
+	var paths []string
+	for _, t := range Toolchains(p) {
+		if t.Dir == "" {
+			continue
+		}
+		bin := t.binDir()
+		if bin != "" {
+			paths = append(paths, bin)
+		}
+		switch {
+		case t.Tool == "java":
+			vars = append(vars, [2]string{"JAVA_HOME", t.Dir})
+		case t.Tool == "go" && bin != "":
+			vars = append(vars, [2]string{"GOROOT", filepath.Dir(bin)})
+		}
+	}
+	if len(paths) > 0 {
+		vars = append(vars, [2]string{"PATH", strings.Join(append(paths, lookup("PATH")), string(filepath.ListSeparator))})
+	}
+
+	return vars, nil
+}
+
+// Environ returns the environment of the current process with the variables
+// of p applied, for commands run in the project.
+func Environ(p Project) ([]string, error) {
+	vars, err := ProjectEnv(p)
+	if err != nil {
+		return nil, err
+	}
+	env := os.Environ()
+	for _, v := range vars {
+		env = append(env, v[0]+"="+v[1])
+	}
+	return env, nil
+}
@@ -0,0 +1,202 @@
+package project
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitDir returns the git directory of the repository at path. Worktrees and
+// submodules have a .git file pointing elsewhere, which is followed.
+func gitDir(path string) (string, bool) {
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return dotGit, true
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", false
+	}
+	dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", false
+	}
+	dir = strings.TrimSpace(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(path, dir)
+	}
+	return dir, true
+}
+
+// commonGitDir returns the directory holding the shared config of the
+// repository, which differs from gitDir for worktrees.
+func commonGitDir(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "commondir"))
+	if err != nil {
+		return dir
+	}
+	common := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(dir, common)
+	}
+	return filepath.Clean(common)
+}
+
+func IsGitRepo(path string) bool {
+	_, ok := gitDir(path)
+	return ok
+}
+
+// GitRemote returns the URL of the "origin" remote of the repository at path,
+// falling back to the first remote defined. It is empty if there is none.
+func GitRemote(path string) string {
+	dir, ok := gitDir(path)
+	if !ok {
+		return ""
+	}
+
+	file, err := os.Open(filepath.Join(commonGitDir(dir), "config"))
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	var section, first string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.TrimSpace(key) != "url" || !strings.HasPrefix(section, "[remote ") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if section == `[remote "origin"]` {
+			return value
+		}
+		if first == "" {
+			first = value
+		}
+	}
+	return first
+}
+
+// GitDefaultBranch returns the branch origin/HEAD points to in the repository
+// at path. It is empty without one, as in repositories that were never
+// cloned; the checked out branch says nothing about the default.
+func GitDefaultBranch(path string) string {
+	dir, ok := gitDir(path)
+	if !ok {
+		return ""
+	}
+	return symbolicBranch(filepath.Join(commonGitDir(dir), "refs", "remotes", "origin", "HEAD"))
+}
+
+// GitBranch returns the branch checked out at path, which may be a linked
+// worktree. It is empty for a detached HEAD.
+func GitBranch(path string) string {
+	dir, ok := gitDir(path)
+	if !ok {
+		return ""
+	}
+	return symbolicBranch(filepath.Join(dir, "HEAD"))
+}
+
+// symbolicBranch returns the branch a symbolic ref file such as HEAD points to.
+func symbolicBranch(ref string) string {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return ""
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: ")
+	if !ok {
+		return ""
+	}
+	for _, prefix := range []string{"refs/remotes/origin/", "refs/heads/"} {
+		if branch, ok := strings.CutPrefix(target, prefix); ok {
+			return branch
+		}
+	}
+	return ""
+}
+
+// NormalizeRemote reduces a git remote URL to "host/owner/repo" so that the
+// https, ssh and scp-like forms of the same repository compare equal.
+func NormalizeRemote(url string) string {
+	url = strings.TrimSpace(url)
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if i := strings.Index(url, ":"); i >= 0 && !strings.Contains(url[:i], "/") {
+		// scp-like syntax: user@host:owner/repo
+		url = url[:i] + "/" + url[i+1:]
+	}
+	if i := strings.Index(url, "@"); i >= 0 && i < strings.Index(url+"/", "/") {
+		url = url[i+1:]
+	}
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+
+	host, rest, _ := strings.Cut(url, "/")
+	if h, _, found := strings.Cut(host, ":"); found {
+		host = h // drop port
+	}
+	return strings.ToLower(host) + "/" + rest
+}
+
+// FindRepoByRemote walks roots looking for a git repository whose remote
+// matches remote. Hidden directories and those matching an ignore glob are
+// skipped, and the search does not descend into repositories or below maxDepth.
+func FindRepoByRemote(roots []string, ignore []string, remote string, maxDepth int) (string, bool) {
+	want := NormalizeRemote(remote)
+
+	var search func(dir string, depth int) (string, bool)
+	search = func(dir string, depth int) (string, bool) {
+		if IsGitRepo(dir) {
+			if NormalizeRemote(GitRemote(dir)) == want {
+				return dir, true
+			}
+			return "", false
+		}
+		if depth >= maxDepth {
+			return "", false
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", false
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || strings.HasPrefix(name, ".") || ignored(name, ignore) {
+				continue
+			}
+			if found, ok := search(filepath.Join(dir, name), depth+1); ok {
+				return found, true
+			}
+		}
+		return "", false
+	}
+
+	for _, root := range roots {
+		if found, ok := search(root, 0); ok {
+			return found, true
+		}
+	}
+	return "", false
+}
+
+func ignored(name string, globs []string) bool {
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Events hooks can be attached to.
+const (
+	HookAdd    = "on_add"
+	HookEdit   = "on_edit"
+	HookRemove = "on_remove"
+	HookOpen   = "on_open"
+	HookDetect = "on_detect"
+)
+
+// Hooks are shell commands run when a project changes. Each receives the
+// project in ASAP_PM_* environment variables and as JSON on stdin.
+type Hooks struct {
+	OnAdd    []string `toml:"on_add,omitempty"`
+	OnEdit   []string `toml:"on_edit,omitempty"`
+	OnRemove []string `toml:"on_remove,omitempty"`
+	OnOpen   []string `toml:"on_open,omitempty"`
+	OnDetect []string `toml:"on_detect,omitempty"`
+}
+
+func (h Hooks) commands(event string) []string {
+	switch event {
+	case HookAdd:
+		return h.OnAdd
+	case HookEdit:
+		return h.OnEdit
+	case HookRemove:
+		return h.OnRemove
+	case HookOpen:
+		return h.OnOpen
+	case HookDetect:
+		return h.OnDetect
+	}
+	return nil
+}
+
+// hookPayload is what hooks read from stdin.
+type hookPayload struct {
+	Event   string  `json:"event"`
+	Profile string  `json:"profile"`
+	Project Project `json:"project"`
+}
+
+// RunHooks runs the global hooks for event and then those of the project's
+// language. Hook output is copied to out unless it is nil; failures are
+// returned together, each with the last line the hook printed. Hooks come
+// from the global config only, never from the project.
+func RunHooks(out io.Writer, event, profile string, p Project) error {
+	settings, err := LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	commands := append(settings.Hooks.commands(event), settings.LanguageHooks[p.Language].commands(event)...)
+	if len(commands) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(hookPayload{event, profile, p})
+	if err != nil {
+		return fmt.Errorf("failed to encode hook input: %w", err)
+	}
+	env := append(os.Environ(),
+		"ASAP_PM_EVENT="+event,
+		"ASAP_PM_PROFILE="+profile,
+		"ASAP_PM_PROJECT_NAME="+p.Name,
+		"ASAP_PM_PROJECT_PATH="+p.Path,
+		"ASAP_PM_PROJECT_LANGUAGE="+p.Language,
+		"ASAP_PM_PROJECT_REMOTE="+p.Remote,
+		"ASAP_PM_PROJECT_TAGS="+strings.Join(p.Tags, ","),
+	)
+
+	var errs []error
+	for _, command := range commands {
+		if err := runHook(command, p.Path, env, payload, settings, out); err != nil {
+			errs = append(errs, fmt.Errorf("%s hook %q: %w", event, command, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runHook(command, dir string, env []string, payload []byte, settings *Settings, out io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if settings.HookTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), settings.HookTimeout)
+	}
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = env
+	cmd.WaitDelay = time.Second // children of the shell may keep its output open
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if out != nil {
+		cmd.Stdout, cmd.Stderr = io.MultiWriter(out, &output), io.MultiWriter(out, &output)
+	}
+	// The project directory is gone after a delete with files
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		cmd.Dir = dir
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out after %s", settings.HookTimeout)
+		}
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		if last := lines[len(lines)-1]; last != "" {
+			return fmt.Errorf("%w: %s", err, last)
+		}
+		return err
+	}
+	return nil
+}
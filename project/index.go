@@ -0,0 +1,169 @@
+package project
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Index is a compact copy of a profile's projects for callers that run on
+// every shell prompt. It is rebuilt when projects.toml changes.
+type Index struct {
+	ModTime int64 // of the projects file the index was built from
+	Size    int64
+	Hash    [sha256.Size]byte
+	Entries []IndexEntry
+}
+
+type IndexEntry struct {
+	Name      string
+	Path      string
+	Canonical string // symlink-resolved Path, what lookups compare against
+	Language  string
+	Modules   []IndexModule
+	Structure int64 // modification time of .asap/project.toml, 0 if missing
+}
+
+type IndexModule struct {
+	Name string
+	Dir  string // canonical
+}
+
+func indexCacheName(profile string) string {
+	return "index-" + profile + ".gob"
+}
+
+// LoadIndex returns the index of profile, rebuilding it when the projects
+// file changed since it was written. Like the config cache, a new
+// modification time alone does not count as a change. A missing cache is
+// not an error.
+func LoadIndex(profile string) (*Index, error) {
+	source, err := profilePath(profile)
+	if err != nil {
+		return nil, err
+	}
+	var modTime, size int64
+	if info, err := os.Stat(source); err == nil {
+		modTime, size = info.ModTime().UnixNano(), info.Size()
+	}
+
+	var cached Index
+	if cacheEnabled && readCache(indexCacheName(profile), &cached) == nil {
+		if cached.ModTime == modTime && cached.Size == size {
+			return &cached, nil
+		}
+		if data, err := os.ReadFile(source); err == nil && sha256.Sum256(data) == cached.Hash {
+			cached.ModTime, cached.Size = modTime, size
+			_ = writeCache(indexCacheName(profile), &cached)
+			return &cached, nil
+		}
+	}
+
+	// Hash what is read before building, a later change must not be
+	// hidden by it
+	var hash [sha256.Size]byte
+	if data, err := os.ReadFile(source); err == nil {
+		hash = sha256.Sum256(data)
+	}
+	config, err := LoadProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	index := BuildIndex(config)
+	index.ModTime, index.Size, index.Hash = modTime, size, hash
+
+	// Failing to cache only makes the next call slower
+	if cacheEnabled {
+		_ = writeCache(indexCacheName(profile), index)
+	}
+	return index, nil
+}
+
+// BuildIndex indexes the active projects of config.
+func BuildIndex(config *Config) *Index {
+	index := &Index{}
+	for _, p := range config.Active() {
+		index.Entries = append(index.Entries, newIndexEntry(p.Name, p.Path, p.Language))
+	}
+	return index
+}
+
+func newIndexEntry(name, path, language string) IndexEntry {
+	entry := IndexEntry{Name: name, Path: path, Canonical: canonicalPath(path), Language: language}
+	entry.loadModules()
+	return entry
+}
+
+// loadModules reads the modules of the project from .asap/project.toml.
+func (e *IndexEntry) loadModules() {
+	e.Modules, e.Structure = nil, 0
+	info, err := os.Stat(StructurePath(e.Path))
+	if err != nil {
+		return
+	}
+	e.Structure = info.ModTime().UnixNano()
+
+	structure, err := LoadStructure(e.Path)
+	if err != nil {
+		return
+	}
+	for _, m := range structure.Modules {
+		if dir := canonicalPath(m.ProjectDir); m.ProjectDir != "" && dir != e.Canonical {
+			e.Modules = append(e.Modules, IndexModule{m.Name, dir})
+		}
+	}
+}
+
+// Lookup returns the entry of the nearest registered ancestor of dir, or of
+// dir itself. Linked worktrees that are not registered resolve to the
+// project of their main checkout.
+func (idx *Index) Lookup(dir string) (*IndexEntry, bool) {
+	dir = canonicalPath(dir)
+	var found *IndexEntry
+	for i := range idx.Entries {
+		e := &idx.Entries[i]
+		if within(dir, e.Canonical) && (found == nil || len(e.Canonical) > len(found.Canonical)) {
+			found = e
+		}
+	}
+	if found != nil {
+		return found, true
+	}
+
+	// Linked worktrees that are not registered belong to their main checkout
+	for d := dir; ; d = filepath.Dir(d) {
+		if main, ok := MainWorktree(d); ok {
+			return idx.Lookup(main)
+		}
+		if filepath.Dir(d) == d {
+			return nil, false
+		}
+	}
+}
+
+// Module returns the name of the module dir is in, refreshing the modules
+// first if .asap/project.toml changed since the index was built.
+func (e *IndexEntry) Module(dir string) string {
+	var modTime int64
+	if info, err := os.Stat(StructurePath(e.Path)); err == nil {
+		modTime = info.ModTime().UnixNano()
+	}
+	if modTime != e.Structure {
+		e.loadModules()
+	}
+
+	dir = canonicalPath(dir)
+	var found IndexModule
+	for _, m := range e.Modules {
+		if within(dir, m.Dir) && len(m.Dir) > len(found.Dir) {
+			found = m
+		}
+	}
+	return found.Name
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,54 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexLookup(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"api/cmd", "api-v2", "shop/libs/core", "elsewhere"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "api"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	index := &Index{Entries: []IndexEntry{
+		newIndexEntry("api", filepath.Join(root, "link"), "go"), // registered through a symlink
+		newIndexEntry("shop", filepath.Join(root, "shop"), "java"),
+		newIndexEntry("core", filepath.Join(root, "shop/libs/core"), "java"),
+	}}
+
+	tests := []struct {
+		dir  string
+		want string // empty when no project contains dir
+	}{
+		{"api", "api"},
+		{"api/cmd", "api"},
+		{"link/cmd", "api"},
+		{"api-v2", ""},
+		{"shop/libs", "shop"},
+		{"shop/libs/core", "core"},
+		{"elsewhere", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			entry, ok := index.Lookup(filepath.Join(root, tt.dir))
+			got := ""
+			if ok {
+				got = entry.Name
+			}
+			if got != tt.want {
+				t.Errorf("Lookup(%s) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
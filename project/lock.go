@@ -0,0 +1,94 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// lockTimeout bounds how long LockProfile waits. Holders only keep the lock
+// for a load-modify-save, never across prompts or clones.
+const lockTimeout = 10 * time.Second
+
+// ErrLocked is returned when another process holds the lock for too long.
+var ErrLocked = errors.New("locked by another asap-pm process")
+
+// LockProfile takes an exclusive lock on the projects file of profile and
+// returns the function releasing it, which may be called more than once.
+// Every load-modify-save of a projects file holds it, so the CLI, the
+// daemon and serve don't drop each other's changes.
+func LockProfile(profile string) (func(), error) {
+	return lockProfile(profile, lockTimeout)
+}
+
+// TryLockProfile is LockProfile without waiting, for the TUI which must
+// not hang on another process.
+func TryLockProfile(profile string) (func(), error) {
+	return lockProfile(profile, 0)
+}
+
+func lockProfile(profile string, timeout time.Duration) (func(), error) {
+	filePath, err := profilePath(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	f, err := os.OpenFile(filePath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", filePath, err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("%s is %w", filePath, ErrLocked)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			_ = unlockFile(f)
+			_ = f.Close()
+		})
+	}, nil
+}
+
+// LockConfig locks the projects file of the active profile.
+func LockConfig() (func(), error) {
+	return LockProfile(ActiveProfile())
+}
+
+// UpdateProfile loads profile, applies fn and saves the result under the
+// profile lock. Nothing is saved when fn fails.
+func UpdateProfile(profile string, fn func(*Config) error) error {
+	unlock, err := LockProfile(profile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := LoadProfile(profile)
+	if err != nil {
+		return err
+	}
+	if err := fn(config); err != nil {
+		return err
+	}
+	return SaveProfile(profile, config)
+}
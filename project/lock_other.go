@@ -0,0 +1,10 @@
+//go:build !unix
+
+package project
+
+import "os"
+
+// Without flock concurrent writers are not serialized.
+func tryLockFile(f *os.File) (bool, error) { return true, nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,155 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FindModule returns the module called name. Besides the module name, the
+// Gradle path (":service:api"), its last segment and the directory relative
+// to the project root are accepted.
+func (s *Structure) FindModule(name string) (Module, bool) {
+	for _, m := range s.Modules {
+		rel, _ := filepath.Rel(s.Project.Root, m.ProjectDir)
+		if m.Name == name || m.Path == name || strings.HasSuffix(m.Path, ":"+name) || rel == filepath.Clean(name) {
+			return m, true
+		}
+	}
+	return Module{}, false
+}
+
+// moduleFor returns the module whose directory holds file most closely.
+func (s *Structure) moduleFor(file string) (Module, bool) {
+	var found Module
+	for _, m := range s.Modules {
+		if m.ProjectDir == "" || len(m.ProjectDir) <= len(found.ProjectDir) {
+			continue
+		}
+		if file == m.ProjectDir || strings.HasPrefix(file, m.ProjectDir+string(filepath.Separator)) {
+			found = m
+		}
+	}
+	return found, found.ProjectDir != ""
+}
+
+// ChangedModules returns the modules with files that differ from the merge
+// base of base and HEAD, including uncommitted and untracked files.
+func (s *Structure) ChangedModules(projectPath, base string) ([]Module, error) {
+	files, err := ChangedFiles(projectPath, base)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []Module
+	for _, file := range files {
+		if m, ok := s.moduleFor(file); ok && !slices.ContainsFunc(modules, func(c Module) bool { return c.ProjectDir == m.ProjectDir }) {
+			modules = append(modules, m)
+		}
+	}
+	return modules, nil
+}
+
+// ModuleCommand rewrites a Gradle or Maven task command to run only in
+// modules: Gradle task names get the module path as prefix and Maven gets a
+// project list with -pl.
+func (s *Structure) ModuleCommand(command string, modules []Module) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", errors.New("empty command")
+	}
+
+	switch filepath.Base(args[0]) {
+	case "gradle", "gradlew":
+		var scoped []string
+		for i, arg := range args[1:] {
+			// Options and their values stay as they are
+			if strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, ":") || (i > 0 && (args[i] == "-x" || args[i] == "--exclude-task")) {
+				scoped = append(scoped, arg)
+				continue
+			}
+			for _, m := range modules {
+				scoped = append(scoped, strings.TrimSuffix(s.gradlePath(m), ":")+":"+arg)
+			}
+		}
+		return strings.Join(append(args[:1], scoped...), " "), nil
+
+	case "mvn", "mvnw":
+		var dirs []string
+		for _, m := range modules {
+			rel, err := filepath.Rel(s.Project.Root, m.ProjectDir)
+			if err != nil {
+				return "", err
+			}
+			dirs = append(dirs, rel)
+		}
+		return strings.Join(append([]string{args[0], "-pl", strings.Join(dirs, ",")}, args[1:]...), " "), nil
+	}
+
+	return "", fmt.Errorf("%q is not a Gradle or Maven command, it cannot run per module", command)
+}
+
+// gradlePath returns the Gradle path of m. Modules found without Gradle have
+// none recorded, their name is used instead.
+func (s *Structure) gradlePath(m Module) string {
+	switch {
+	case m.Path != "":
+		return m.Path
+	case filepath.Clean(m.ProjectDir) == filepath.Clean(s.Project.Root):
+		return ":"
+	}
+	return ":" + m.Name
+}
+
+// DefaultBase returns the branch origin/HEAD points to, or "main".
+func DefaultBase(path string) string {
+	out, err := exec.Command("git", "-C", path, "rev-parse", "--abbrev-ref", "origin/HEAD").Output()
+	if base := strings.TrimSpace(string(out)); err == nil && base != "" && base != "origin/HEAD" {
+		return base
+	}
+	return "main"
+}
+
+// ChangedFiles returns the absolute paths of files in the repository at path
+// that differ from the merge base of base and HEAD.
+func ChangedFiles(path, base string) ([]string, error) {
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", path}, args...)...).Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := git("merge-base", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	changed, err := git("diff", "--name-only", mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(changed+"\n"+untracked, "\n") {
+		if name != "" {
+			files = append(files, filepath.Join(top, name))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,40 @@
+package project
+
+import "testing"
+
+func TestModuleCommand(t *testing.T) {
+	s := &Structure{
+		Project: StructureInfo{Root: "/src/shop"},
+	}
+	api := Module{Name: "api", Path: ":service:api", ProjectDir: "/src/shop/service/api"}
+	web := Module{Name: "web", ProjectDir: "/src/shop/web"}
+	root := Module{Name: "shop", ProjectDir: "/src/shop"}
+
+	tests := []struct {
+		name    string
+		command string
+		modules []Module
+		want    string
+		wantErr bool
+	}{
+		{"gradle task", "./gradlew test", []Module{api}, "./gradlew :service:api:test", false},
+		{"gradle several modules", "gradle build", []Module{api, web}, "gradle :service:api:build :web:build", false},
+		{"gradle options kept", "./gradlew test --info -x lint", []Module{api}, "./gradlew :service:api:test --info -x lint", false},
+		{"gradle root module", "./gradlew check", []Module{root}, "./gradlew :check", false},
+		{"maven", "mvn -q verify", []Module{api, web}, "mvn -pl service/api,web -q verify", false},
+		{"other tool", "make test", []Module{api}, "", true},
+		{"empty", "  ", []Module{api}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ModuleCommand(tt.command, tt.modules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ModuleCommand(%q) error = %v, want error %v", tt.command, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ModuleCommand(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandPath expands environment variables ("$WORK/api") and a leading "~"
+// in path. Relative paths are resolved against the home directory.
+func ExpandPath(path string) string {
+	return ExpandHome(ExpandEnv(path))
+}
+
+// ExpandEnv replaces $VAR and ${VAR} with the value of variables that are
+// set. Anything else, including a "$" that is part of a directory name,
+// stays as written, so an unset $WORK never turns "$WORK/api" into "/api".
+func ExpandEnv(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '$' {
+			b.WriteByte(path[i])
+			continue
+		}
+
+		rest, braced := path[i+1:], false
+		if strings.HasPrefix(rest, "{") {
+			rest, braced = rest[1:], true
+		}
+		n := 0
+		for n < len(rest) && isNameByte(rest[n], n == 0) {
+			n++
+		}
+		if braced && (n == 0 || n == len(rest) || rest[n] != '}') {
+			n = 0
+		}
+		value, ok := os.LookupEnv(rest[:n])
+		if n == 0 || !ok {
+			b.WriteByte('$')
+			continue
+		}
+
+		b.WriteString(value)
+		i += n
+		if braced {
+			i += 2
+		}
+	}
+	return b.String()
+}
+
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || !first && '0' <= c && c <= '9'
+}
+
+// ExpandHome resolves a leading "~" and paths relative to the home
+// directory, without touching "$".
+func ExpandHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	if path == "~" {
+		return home
+	}
+	if rest, ok := strings.CutPrefix(path, "~"+string(filepath.Separator)); ok {
+		path = rest
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(home, path)
+	}
+	return path
+}
+
+// CompactPath rewrites an absolute path under the home directory as "~/...",
+// so the stored config stays valid on machines with a different home.
+func CompactPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	rel, err := filepath.Rel(home, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	if rel == "." {
+		return "~"
+	}
+	return "~" + string(filepath.Separator) + rel
+}
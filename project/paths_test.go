@@ -0,0 +1,83 @@
+package project
+
+import "testing"
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("WORK", "/work")
+	t.Setenv("EMPTY", "")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$WORK/api", "/work/api"},
+		{"${WORK}/api", "/work/api"},
+		{"$WORKSPACE/api", "$WORKSPACE/api"},
+		{"$UNSET_FOR_TEST/api", "$UNSET_FOR_TEST/api"},
+		{"${UNSET_FOR_TEST}/api", "${UNSET_FOR_TEST}/api"},
+		{"$EMPTY/api", "/api"},
+		{"/srv/$/cost$", "/srv/$/cost$"},
+		{"/srv/${WORK", "/srv/${WORK"},
+		{"/srv/$1", "/srv/$1"},
+		{"$WORK$WORK", "/work/work"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ExpandEnv(tt.path); got != tt.want {
+				t.Errorf("ExpandEnv(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	t.Setenv("WORK", "~/work")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~", "/home/dev"},
+		{"~/src/api", "/home/dev/src/api"},
+		{"src/api", "/home/dev/src/api"},
+		{"/srv/api", "/srv/api"},
+		{"$WORK/api", "/home/dev/work/api"},
+		{"$UNSET_FOR_TEST/api", "/home/dev/$UNSET_FOR_TEST/api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ExpandPath(tt.path); got != tt.want {
+				t.Errorf("ExpandPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompactPath(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/dev", "~"},
+		{"/home/dev/src/api", "~/src/api"},
+		{"/home/developer/api", "/home/developer/api"},
+		{"/srv/api", "/srv/api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := CompactPath(tt.path)
+			if got != tt.want {
+				t.Errorf("CompactPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+			if back := ExpandPath(got); back != tt.path {
+				t.Errorf("ExpandPath(CompactPath(%q)) = %q", tt.path, back)
+			}
+		})
+	}
+}
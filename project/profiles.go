@@ -0,0 +1,75 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is stored in projects.toml, every other profile in
+// profiles/<name>.toml next to it.
+const DefaultProfile = "default"
+
+var activeProfile string
+
+// UseProfile selects the profile read and written by LoadConfig and
+// SaveConfig. An empty name falls back to $ASAP_PM_PROFILE.
+func UseProfile(name string) {
+	activeProfile = name
+}
+
+func ActiveProfile() string {
+	if activeProfile != "" {
+		return activeProfile
+	}
+	if env := os.Getenv("ASAP_PM_PROFILE"); env != "" {
+		return env
+	}
+	return DefaultProfile
+}
+
+func profilePath(profile string) (string, error) {
+	if profile == "" || strings.ContainsAny(profile, `/\`) || strings.HasPrefix(profile, ".") {
+		return "", fmt.Errorf("invalid profile name %q", profile)
+	}
+
+	configPath, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	if profile == DefaultProfile {
+		return filepath.Join(configPath, "projects.toml"), nil
+	}
+	return filepath.Join(configPath, "profiles", profile+".toml"), nil
+}
+
+// ProfileFile returns the projects file of profile.
+func ProfileFile(profile string) (string, error) {
+	return profilePath(profile)
+}
+
+// Profiles returns the names of all profiles, the default one first.
+func Profiles() ([]string, error) {
+	configPath, err := ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(configPath, "profiles"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".toml"); ok && !entry.IsDir() {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+
+	return append([]string{DefaultProfile}, profiles...), nil
+}
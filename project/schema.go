@@ -0,0 +1,172 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SchemaVersion is the version of projects.toml written by this build.
+// Files without a version key are version 0.
+const SchemaVersion = 1
+
+// migrations[i] upgrades the raw content of a version i file to version i+1.
+var migrations = []func(raw map[string]any){
+	func(raw map[string]any) {}, // version 1 only adds the version key
+}
+
+func rawVersion(raw map[string]any) int {
+	version, _ := raw["version"].(int64)
+	return int(version)
+}
+
+// decodeConfig migrates data to the current schema and decodes it. Keys this
+// build does not know about are kept on the Config and its projects so that
+// SaveConfig writes them back. Files from a newer schema are decoded as is.
+func decodeConfig(data []byte) (*Config, error) {
+	var raw map[string]any
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, err
+	}
+
+	// Check types here, where positions still refer to the original file
+	if rawVersion(raw) <= SchemaVersion {
+		if errs := checkTypes(raw, strings.Split(string(data), "\n")); len(errs) > 0 {
+			return nil, errs
+		}
+	}
+
+	for v := rawVersion(raw); v < SchemaVersion; v++ {
+		migrations[v](raw)
+	}
+
+	var migrated bytes.Buffer
+	if err := toml.NewEncoder(&migrated).Encode(raw); err != nil {
+		return nil, err
+	}
+
+	var config Config
+	md, err := toml.Decode(migrated.String(), &config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Undecoded keys of array tables carry no index, but a key unknown in
+	// one project is unknown in all of them
+	unknown := map[string]map[string]bool{}
+	for _, key := range md.Undecoded() {
+		section := key[0]
+		if unknown[section] == nil {
+			unknown[section] = map[string]bool{}
+		}
+		if len(key) > 1 {
+			unknown[section][key[1]] = true
+		}
+	}
+
+	for name := range unknown {
+		if name == "projects" || name == "trash" {
+			continue
+		}
+		if config.extra == nil {
+			config.extra = map[string]any{}
+		}
+		config.extra[name] = raw[name]
+	}
+	keepProjectExtras(raw["projects"], unknown["projects"], func(i int) *Project { return &config.Projects[i] })
+	keepProjectExtras(raw["trash"], unknown["trash"], func(i int) *Project { return &config.Trash[i].Project })
+
+	return &config, nil
+}
+
+func keepProjectExtras(section any, keys map[string]bool, project func(i int) *Project) {
+	tables, _ := section.([]map[string]any)
+	for i, table := range tables {
+		for key, value := range table {
+			if !keys[key] {
+				continue
+			}
+			p := project(i)
+			if p.extra == nil {
+				p.extra = map[string]any{}
+			}
+			p.extra[key] = value
+		}
+	}
+}
+
+// hasExtra reports whether c holds keys unknown to this build.
+func (c *Config) hasExtra() bool {
+	hasExtra := len(c.extra) > 0
+	for _, p := range c.Projects {
+		hasExtra = hasExtra || len(p.extra) > 0
+	}
+	for _, t := range c.Trash {
+		hasExtra = hasExtra || len(t.extra) > 0
+	}
+	return hasExtra
+}
+
+// encodeConfig encodes config, adding back the keys kept by decodeConfig.
+func encodeConfig(config *Config) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(config); err != nil {
+		return nil, err
+	}
+
+	if !config.hasExtra() {
+		return buf.Bytes(), nil
+	}
+
+	var raw map[string]any
+	if _, err := toml.Decode(buf.String(), &raw); err != nil {
+		return nil, err
+	}
+	for key, value := range config.extra {
+		raw[key] = value
+	}
+	addProjectExtras(raw["projects"], len(config.Projects), func(i int) Project { return config.Projects[i] })
+	addProjectExtras(raw["trash"], len(config.Trash), func(i int) Project { return config.Trash[i].Project })
+
+	buf.Reset()
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func addProjectExtras(section any, n int, project func(i int) Project) {
+	tables, _ := section.([]map[string]any)
+	for i := 0; i < len(tables) && i < n; i++ {
+		for key, value := range project(i).extra {
+			tables[i][key] = value
+		}
+	}
+}
+
+// checkWritable refuses to overwrite a file written by a newer schema, whose
+// data this build would silently drop.
+func checkWritable(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var header struct {
+		Version int `toml:"version"`
+	}
+	if _, err := toml.Decode(string(data), &header); err != nil {
+		// A broken file holds nothing worth protecting
+		return nil
+	}
+	if header.Version > SchemaVersion {
+		return fmt.Errorf("%s uses schema version %d but this asap-pm supports up to %d, please upgrade", filePath, header.Version, SchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestConfigRoundTripKeepsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]any // keys expected in the saved file
+	}{
+		{
+			name: "top-level key",
+			data: "version = 1\nowner = \"team\"\n\n[[projects]]\nname = \"a\"\npath = \"/a\"\nlanguage = \"go\"\n",
+			want: map[string]any{"owner": "team"},
+		},
+		{
+			name: "top-level table",
+			data: "version = 1\n\n[sync]\ninterval = 5\n\n[[projects]]\nname = \"a\"\npath = \"/a\"\nlanguage = \"go\"\n",
+			want: map[string]any{"sync": map[string]any{"interval": int64(5)}},
+		},
+		{
+			name: "project key",
+			data: "version = 1\n\n[[projects]]\nname = \"a\"\npath = \"/a\"\nlanguage = \"go\"\nicon = \"rocket\"\n",
+			want: map[string]any{"projects": []map[string]any{{"name": "a", "path": "/a", "language": "go", "icon": "rocket"}}},
+		},
+		{
+			name: "trash key",
+			data: "version = 1\nprojects = []\n\n[[trash]]\nname = \"a\"\npath = \"/a\"\nlanguage = \"go\"\ndeleted_at = 2024-01-02T03:04:05Z\nindex = 0\nicon = \"rocket\"\n",
+			want: map[string]any{"trash": []map[string]any{{"icon": "rocket"}}},
+		},
+		{
+			name: "version 0 keeps custom language",
+			data: "[[projects]]\nname = \"a\"\npath = \"/a\"\nlanguage = \"Kotlin\"\ncolor = \"red\"\n",
+			want: map[string]any{"version": int64(1), "projects": []map[string]any{{"name": "a", "path": "/a", "language": "Kotlin", "color": "red"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := decodeConfig([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("decodeConfig: %v", err)
+			}
+			config.Version = SchemaVersion
+			data, err := encodeConfig(config)
+			if err != nil {
+				t.Fatalf("encodeConfig: %v", err)
+			}
+
+			var saved map[string]any
+			if _, err := toml.Decode(string(data), &saved); err != nil {
+				t.Fatalf("saved file does not parse: %v\n%s", err, data)
+			}
+			for key, want := range tt.want {
+				if !contains(saved[key], want) {
+					t.Errorf("%s = %#v, want %#v\n%s", key, saved[key], want, data)
+				}
+			}
+		})
+	}
+}
+
+// contains reports whether got has every key of want, recursing into tables
+// and arrays of tables.
+func contains(got, want any) bool {
+	switch want := want.(type) {
+	case map[string]any:
+		got, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range want {
+			if !contains(got[key], value) {
+				return false
+			}
+		}
+		return true
+	case []map[string]any:
+		got, ok := got.([]map[string]any)
+		if !ok || len(got) != len(want) {
+			return false
+		}
+		for i := range want {
+			if !contains(got[i], want[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(got, want)
+}
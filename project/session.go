@@ -0,0 +1,50 @@
+package project
+
+import (
+	"os/exec"
+	"path/filepath"
+	"slices"
+)
+
+// SessionFile returns where the Vim session of p is kept: Session.vim in
+// the project, as written by :mksession there, or <name>.vim in
+// session_dir when that is set.
+func (s *Settings) SessionFile(p Project) string {
+	if s.SessionDir == "" {
+		return filepath.Join(p.Path, "Session.vim")
+	}
+	return filepath.Join(ExpandPath(s.SessionDir), p.Name+".vim")
+}
+
+// Session returns the session file of p and whether it can be sourced: it
+// exists and comes from session_dir or is not tracked by git. A Session.vim
+// checked into a repository would run its commands on open.
+func (s *Settings) Session(p Project) (string, bool) {
+	file := s.SessionFile(p)
+	if !exists(file) {
+		return file, false
+	}
+	if s.SessionDir != "" {
+		return file, true
+	}
+	tracked := exec.Command("git", "-C", p.Path, "ls-files", "--error-unmatch", "--", filepath.Base(file)).Run() == nil
+	return file, !tracked
+}
+
+// RootMarkers returns the files and directories in the project root that
+// editors use to find it, such as .git and the build files.
+func RootMarkers(path string) []string {
+	candidates := []string{".git", ".asap"}
+	for file := range languageMarkers {
+		candidates = append(candidates, file)
+	}
+	slices.Sort(candidates)
+
+	var markers []string
+	for _, name := range candidates {
+		if exists(filepath.Join(path, name)) {
+			markers = append(markers, name)
+		}
+	}
+	return markers
+}
@@ -0,0 +1,291 @@
+package project
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Settings are the global options from config.toml. A project can override
+// them in its own .asap/config.toml.
+type Settings struct {
+	Editor        string            `toml:"editor,omitempty"`
+	Launcher      string            `toml:"launcher,omitempty"`  // launcher used by "open" by default
+	Launchers     map[string]string `toml:"launchers,omitempty"` // name to command, see LaunchCommand
+	BaseDir       string            `toml:"base_dir,omitempty"`  // relative project paths start here
+	ScanRoots     []string          `toml:"scan_roots,omitempty"`
+	CloneRoot     string            `toml:"clone_root,omitempty"`   // where missing repositories are cloned
+	CloneLayout   string            `toml:"clone_layout,omitempty"` // path below clone_root, see ClonePath
+	Ignore        []string          `toml:"ignore,omitempty"`       // directory globs skipped while scanning
+	Theme         string            `toml:"theme,omitempty"`        // accent color of the TUI, e.g. "212" or "#7D56F4"
+	DetectTimeout time.Duration     `toml:"detect_timeout,omitempty"`
+	Hooks         Hooks             `toml:"hooks,omitempty"`
+	LanguageHooks map[string]Hooks  `toml:"language_hooks,omitempty"` // language to hooks run after the global ones
+	HookTimeout   time.Duration     `toml:"hook_timeout,omitempty"`
+	SessionDir    string            `toml:"session_dir,omitempty"` // Vim sessions as <name>.vim, instead of Session.vim in each project
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Editor:   "nvim",
+		Launcher: "editor",
+		Launchers: map[string]string{
+			"editor": "{editor} .",
+			"nvim":   "nvim {session}",
+			"shell":  "${SHELL:-sh}",
+		},
+		BaseDir:       "~",
+		ScanRoots:     []string{"~"},
+		CloneRoot:     "~/src",
+		CloneLayout:   "{host}/{owner}/{repo}",
+		Ignore:        []string{"node_modules", "target", "build", "vendor"},
+		DetectTimeout: 5 * time.Minute,
+		HookTimeout:   30 * time.Second,
+	}
+}
+
+func SettingsPath() (string, error) {
+	configPath, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, "config.toml"), nil
+}
+
+func LocalSettingsPath(projectPath string) string {
+	return filepath.Join(projectPath, ".asap", "config.toml")
+}
+
+// LoadSettings returns the defaults overridden by the global config.toml.
+func LoadSettings() (*Settings, error) {
+	settings := DefaultSettings()
+
+	filePath, err := SettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeSettings(filePath, &settings); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// globalSettings hold commands run through the shell. A repository could
+// ship them in its .asap/config.toml, so they are only read from the
+// global config.toml.
+var globalSettings = []string{"editor", "launchers", "hooks", "language_hooks", "session_dir"}
+
+// IsGlobalSetting reports whether key can only be set in the global config.
+func IsGlobalSetting(key string) bool {
+	name, _, _ := strings.Cut(key, ".")
+	return slices.Contains(globalSettings, name)
+}
+
+// LoadProjectSettings returns the global settings overridden by the
+// .asap/config.toml of the project at projectPath. Global only settings in
+// that file are ignored, so it can pick a launcher but not define one.
+func LoadProjectSettings(projectPath string) (*Settings, error) {
+	settings, err := LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	global := *settings
+	if err := decodeSettings(LocalSettingsPath(projectPath), settings); err != nil {
+		return nil, err
+	}
+	settings.Editor, settings.Launchers = global.Editor, global.Launchers
+	settings.Hooks, settings.LanguageHooks = global.Hooks, global.LanguageHooks
+	settings.SessionDir = global.SessionDir
+	return settings, nil
+}
+
+// decodeSettings overlays the keys set in filePath onto settings. Maps are
+// merged, every other value is replaced. A missing file is not an error.
+func decodeSettings(filePath string, settings *Settings) error {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil
+	}
+
+	settings.Launchers = maps.Clone(settings.Launchers)
+	settings.LanguageHooks = maps.Clone(settings.LanguageHooks)
+	if _, err := toml.DecodeFile(filePath, settings); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// ReadSettingsFile returns only what is set in filePath, without defaults.
+func ReadSettingsFile(filePath string) (*Settings, error) {
+	var settings Settings
+	if err := decodeSettings(filePath, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func WriteSettingsFile(filePath string, settings *Settings) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create settings file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := toml.NewEncoder(file).Encode(settings); err != nil {
+		return fmt.Errorf("failed to encode settings to TOML: %w", err)
+	}
+	return nil
+}
+
+// Roots returns the scan roots with "~" and environment variables expanded.
+func (s *Settings) Roots() []string {
+	roots := make([]string, len(s.ScanRoots))
+	for i, root := range s.ScanRoots {
+		roots[i] = ExpandPath(root)
+	}
+	return roots
+}
+
+// ClonePath returns where the repository at remote is cloned: the clone
+// layout below the clone root, with {host}, {owner} and {repo} taken from
+// the remote. Owners may span several path elements, as in GitLab groups.
+func (s *Settings) ClonePath(remote string) string {
+	normalized := NormalizeRemote(remote)
+	host, rest, _ := strings.Cut(normalized, "/")
+	owner, repo := filepath.Split(rest)
+
+	layout := s.CloneLayout
+	if layout == "" {
+		layout = "{repo}"
+	}
+	return filepath.Join(ExpandPath(s.CloneRoot), strings.NewReplacer(
+		"{host}", host,
+		"{owner}", strings.Trim(owner, "/"),
+		"{repo}", repo,
+	).Replace(layout))
+}
+
+// settingsField finds the struct field for a key such as "editor" or
+// "launchers.code". The second result is the map key, if any.
+func (s *Settings) settingsField(key string) (reflect.Value, string, error) {
+	name, mapKey, _ := strings.Cut(key, ".")
+
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("toml"), ",")
+		if tag != name {
+			continue
+		}
+		field := v.Field(i)
+		if (field.Kind() == reflect.Map) != (mapKey != "") {
+			return reflect.Value{}, "", fmt.Errorf("invalid settings key %q", key)
+		}
+		return field, mapKey, nil
+	}
+	return reflect.Value{}, "", fmt.Errorf("unknown settings key %q", key)
+}
+
+// Get returns the value of key formatted for display. Lists are comma
+// separated.
+func (s *Settings) Get(key string) (string, error) {
+	field, mapKey, err := s.settingsField(key)
+	if err != nil {
+		return "", err
+	}
+
+	switch value := field.Interface().(type) {
+	case map[string]string:
+		return value[mapKey], nil
+	case []string:
+		return strings.Join(value, ","), nil
+	case time.Duration:
+		return value.String(), nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+// Set parses value and assigns it to key. Lists are comma separated and
+// durations use Go syntax ("30s", "5m"). An empty value clears the key.
+func (s *Settings) Set(key, value string) error {
+	field, mapKey, err := s.settingsField(key)
+	if err != nil {
+		return err
+	}
+
+	switch field.Interface().(type) {
+	case map[string]string:
+		if field.IsNil() {
+			field.Set(reflect.ValueOf(map[string]string{}))
+		}
+		if value == "" {
+			field.SetMapIndex(reflect.ValueOf(mapKey), reflect.Value{})
+		} else {
+			field.SetMapIndex(reflect.ValueOf(mapKey), reflect.ValueOf(value))
+		}
+	case []string:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case time.Duration:
+		var d time.Duration
+		if value != "" {
+			if d, err = time.ParseDuration(value); err != nil {
+				return fmt.Errorf("invalid duration for %s: %w", key, err)
+			}
+		}
+		field.Set(reflect.ValueOf(d))
+	case string:
+		field.SetString(value)
+	default:
+		return fmt.Errorf("%s cannot be set from the command line, use 'asap-pm config edit'", key)
+	}
+	return nil
+}
+
+// LaunchCommand returns the shell command of the named launcher with
+// {editor}, {path} and {name} substituted for p. {session} becomes
+// "-S <session file>" when p has a session and "." otherwise.
+func (s *Settings) LaunchCommand(launcher string, p Project) (string, error) {
+	if launcher == "" {
+		launcher = s.Launcher
+	}
+	command, ok := s.Launchers[launcher]
+	if !ok {
+		return "", fmt.Errorf("unknown launcher %q", launcher)
+	}
+
+	session := "."
+	if strings.Contains(command, "{session}") {
+		if file, ok := s.Session(p); ok {
+			session = "-S " + ShellQuote(file)
+		}
+	}
+
+	return strings.NewReplacer(
+		"{editor}", s.Editor,
+		"{path}", ShellQuote(p.Path),
+		"{name}", ShellQuote(p.Name),
+		"{session}", session,
+	).Replace(command), nil
+}
+
+// ShellQuote quotes s as a single sh word.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,57 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Structure is the content of .asap/project.toml, written by the Java
+// project structure detector. Tasks are written by hand and kept when the
+// detector runs again.
+type Structure struct {
+	Project StructureInfo     `toml:"project" json:"project"`
+	Modules []Module          `toml:"modules" json:"modules"`
+	Tasks   map[string]string `toml:"tasks" json:"tasks"`
+}
+
+type StructureInfo struct {
+	Type             string `toml:"type" json:"type"`
+	Root             string `toml:"root" json:"root"`
+	BuildToolVersion string `toml:"build_tool_version" json:"build_tool_version"`
+}
+
+type Module struct {
+	Name             string   `toml:"name" json:"name"`
+	Path             string   `toml:"path" json:"path"`
+	ProjectDir       string   `toml:"project_dir" json:"project_dir,omitempty"`
+	BuildDir         string   `toml:"build_dir" json:"build_dir,omitempty"`
+	BuildFile        string   `toml:"build_file" json:"build_file,omitempty"`
+	SourceDirs       []string `toml:"source_dirs" json:"source_dirs,omitempty"`
+	ResourceDirs     []string `toml:"resource_dirs" json:"resource_dirs,omitempty"`
+	TestSourceDirs   []string `toml:"test_source_dirs" json:"test_source_dirs,omitempty"`
+	TestResourceDirs []string `toml:"test_resource_dirs" json:"test_resource_dirs,omitempty"`
+}
+
+func StructurePath(projectPath string) string {
+	return filepath.Join(projectPath, ".asap", "project.toml")
+}
+
+// LoadStructure reads .asap/project.toml of the project at projectPath. The
+// returned error satisfies os.IsNotExist when the file has not been generated.
+func LoadStructure(projectPath string) (*Structure, error) {
+	filePath := StructurePath(projectPath)
+
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, err
+	}
+
+	var structure Structure
+	if _, err := toml.DecodeFile(filePath, &structure); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", filePath, err)
+	}
+
+	return &structure, nil
+}
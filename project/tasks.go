@@ -0,0 +1,142 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+)
+
+// buildWrapper returns the wrapper script in projectPath if there is one,
+// otherwise the build tool itself, like the structure detector does.
+func buildWrapper(projectPath, wrapper, tool string) string {
+	if _, err := os.Stat(filepath.Join(projectPath, wrapper)); err == nil {
+		return "./" + wrapper
+	}
+	return tool
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// DefaultTasks returns the tasks every project of p's language gets.
+func DefaultTasks(p Project) map[string]string {
+	switch p.Language {
+	case "go":
+		return map[string]string{
+			"build": "go build ./...",
+			"test":  "go test ./...",
+			"run":   "go run .",
+			"lint":  "go vet ./...",
+		}
+	case "rust":
+		return map[string]string{
+			"build": "cargo build",
+			"test":  "cargo test",
+			"run":   "cargo run",
+			"lint":  "cargo clippy",
+		}
+	case "javascript":
+		return map[string]string{
+			"build": "npm run build",
+			"test":  "npm test",
+			"run":   "npm start",
+			"lint":  "npm run lint",
+		}
+	case "python":
+		return map[string]string{
+			"test": "python -m pytest",
+			"lint": "python -m ruff check .",
+		}
+	case "java":
+		if exists(filepath.Join(p.Path, "pom.xml")) {
+			mvn := buildWrapper(p.Path, "mvnw", "mvn")
+			return map[string]string{
+				"build": mvn + " package -DskipTests",
+				"test":  mvn + " verify",
+				"lint":  mvn + " validate",
+			}
+		}
+		gradle := buildWrapper(p.Path, "gradlew", "gradle")
+		return map[string]string{
+			"build": gradle + " build -x test",
+			"test":  gradle + " test",
+			"run":   gradle + " run",
+			"lint":  gradle + " check -x test",
+		}
+	case "c":
+		return map[string]string{
+			"build": "make",
+			"test":  "make test",
+		}
+	}
+	return map[string]string{}
+}
+
+// Tasks returns the named commands of p: the language defaults overridden by
+// the [tasks] table of .asap/project.toml. An empty command removes a task.
+func Tasks(p Project) (map[string]string, error) {
+	tasks := DefaultTasks(p)
+
+	structure, err := LoadStructure(p.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if structure != nil {
+		for name, command := range structure.Tasks {
+			if command == "" {
+				delete(tasks, name)
+			} else {
+				tasks[name] = command
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// TaskNames returns the task names in a stable order.
+func TaskNames(tasks map[string]string) []string {
+	return slices.Sorted(maps.Keys(tasks))
+}
+
+// StructureTasks returns the [tasks] table of .asap/project.toml as written,
+// so it can be put back after the detector regenerates the file.
+func StructureTasks(projectPath string) map[string]string {
+	structure, err := LoadStructure(projectPath)
+	if err != nil {
+		return nil
+	}
+	return structure.Tasks
+}
+
+// AppendStructureTasks adds a [tasks] table to .asap/project.toml.
+func AppendStructureTasks(projectPath string, tasks map[string]string) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\n")
+	if err := toml.NewEncoder(&buf).Encode(struct {
+		Tasks map[string]string `toml:"tasks"`
+	}{tasks}); err != nil {
+		return fmt.Errorf("failed to encode tasks: %w", err)
+	}
+
+	file, err := os.OpenFile(StructurePath(projectPath), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open structure file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write tasks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package project
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+type MergeAction int
+
+const (
+	MergeAdd MergeAction = iota
+	MergeUpdate
+	MergeSkip
+	MergeConflict // a local project has the name but another remote
+)
+
+func (a MergeAction) String() string {
+	switch a {
+	case MergeAdd:
+		return "add"
+	case MergeUpdate:
+		return "update"
+	case MergeConflict:
+		return "conflict"
+	}
+	return "skip"
+}
+
+// MergeResult describes what Merge did with one upstream project. Index is
+// its position in Config.Projects unless it was skipped.
+type MergeResult struct {
+	Project Project
+	Action  MergeAction
+	Index   int
+}
+
+// Merge applies the projects of a shared registry to c. Existing projects are
+// matched by git remote, or by name when either has none, and are only
+// changed when update is set; local paths, pins, archive state and tags are
+// kept, upstream tags are added. A local project with the same name but
+// another remote is a conflict and left alone. Added projects are placed
+// with placePath and every touched project records source as its
+// provenance.
+func (c *Config) Merge(upstream []Project, source string, update bool, placePath func(Project) string) []MergeResult {
+	results := make([]MergeResult, 0, len(upstream))
+
+	for _, up := range upstream {
+		idx, found := c.findUpstream(up)
+		switch {
+		case !found:
+			if i, exists := c.Find(up.Name); exists {
+				results = append(results, MergeResult{c.Projects[i], MergeConflict, -1})
+				continue
+			}
+			up.Path = placePath(up)
+			up.Source = source
+			up.Pinned, up.Archived = false, false
+			c.Projects = append(c.Projects, up)
+			results = append(results, MergeResult{up, MergeAdd, len(c.Projects) - 1})
+
+		case update:
+			local := &c.Projects[idx]
+			if up.Language != "" {
+				local.Language = up.Language
+			}
+			if up.Remote != "" {
+				local.Remote = up.Remote
+			}
+			if up.DefaultBranch != "" {
+				local.DefaultBranch = up.DefaultBranch
+			}
+			for _, tag := range up.Tags {
+				if !slices.Contains(local.Tags, tag) {
+					local.Tags = append(local.Tags, tag)
+				}
+			}
+			local.Source = source
+			results = append(results, MergeResult{*local, MergeUpdate, idx})
+
+		default:
+			results = append(results, MergeResult{c.Projects[idx], MergeSkip, -1})
+		}
+	}
+
+	if !slices.Contains(c.Imports, source) {
+		c.Imports = append(c.Imports, source)
+	}
+	return results
+}
+
+func (c *Config) findUpstream(up Project) (int, bool) {
+	if up.Remote != "" {
+		want := NormalizeRemote(up.Remote)
+		for i, p := range c.Projects {
+			if p.Remote != "" && NormalizeRemote(p.Remote) == want {
+				return i, true
+			}
+		}
+	}
+
+	// Without a remote on either side the name is all there is
+	idx, found := c.Find(up.Name)
+	if found && (up.Remote == "" || c.Projects[idx].Remote == "") {
+		return idx, true
+	}
+	return -1, false
+}
+
+// RepoName returns the last path element of a remote URL without ".git".
+func RepoName(remote string) string {
+	return path.Base(strings.TrimSuffix(NormalizeRemote(remote), "/"))
+}
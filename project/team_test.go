@@ -0,0 +1,84 @@
+package project
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigMerge(t *testing.T) {
+	place := func(p Project) string { return filepath.Join("/clones", p.Name) }
+	local := []Project{
+		{Name: "api", Path: "/src/api", Language: "go", Remote: "git@github.com:acme/api.git", Tags: []string{"mine"}, Pinned: true},
+		{Name: "web", Path: "/src/web", Language: "javascript"},
+		{Name: "tool", Path: "/src/tool", Remote: "https://github.com/me/tool"},
+	}
+
+	tests := []struct {
+		name     string
+		upstream Project
+		update   bool
+		action   MergeAction
+		want     Project // the project at the result index, if any
+	}{
+		{
+			name:     "new project is placed",
+			upstream: Project{Name: "db", Path: "ignored", Remote: "https://github.com/acme/db", Pinned: true},
+			action:   MergeAdd,
+			want:     Project{Name: "db", Path: "/clones/db", Remote: "https://github.com/acme/db", Source: "team.toml"},
+		},
+		{
+			name:     "same remote in another form is skipped",
+			upstream: Project{Name: "backend", Remote: "https://github.com/acme/api"},
+			action:   MergeSkip,
+		},
+		{
+			name:     "update keeps local fields and unions tags",
+			upstream: Project{Name: "backend", Language: "rust", Remote: "https://github.com/acme/api", Tags: []string{"team", "mine"}},
+			update:   true,
+			action:   MergeUpdate,
+			want:     Project{Name: "api", Path: "/src/api", Language: "rust", Remote: "https://github.com/acme/api", Tags: []string{"mine", "team"}, Pinned: true, Source: "team.toml"},
+		},
+		{
+			name:     "local project without remote matches by name",
+			upstream: Project{Name: "web", Remote: "https://github.com/acme/web"},
+			update:   true,
+			action:   MergeUpdate,
+			want:     Project{Name: "web", Path: "/src/web", Language: "javascript", Remote: "https://github.com/acme/web", Source: "team.toml"},
+		},
+		{
+			name:     "same name with another remote conflicts",
+			upstream: Project{Name: "tool", Remote: "https://github.com/acme/tool"},
+			update:   true,
+			action:   MergeConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{}
+			for _, p := range local {
+				p.Tags = append([]string(nil), p.Tags...)
+				config.Projects = append(config.Projects, p)
+			}
+
+			results := config.Merge([]Project{tt.upstream}, "team.toml", tt.update, place)
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			if results[0].Action != tt.action {
+				t.Fatalf("action = %s, want %s", results[0].Action, tt.action)
+			}
+			if tt.action == MergeAdd || tt.action == MergeUpdate {
+				if got := config.Projects[results[0].Index]; !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("project = %+v, want %+v", got, tt.want)
+				}
+			} else if !reflect.DeepEqual(config.Projects, local) {
+				t.Errorf("projects changed to %+v", config.Projects)
+			}
+			if !reflect.DeepEqual(config.Imports, []string{"team.toml"}) {
+				t.Errorf("imports = %v", config.Imports)
+			}
+		})
+	}
+}
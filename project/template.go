@@ -0,0 +1,197 @@
+package project
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+)
+
+// templateManifest is the optional file describing a template. It is not
+// copied into new projects.
+const templateManifest = "template.toml"
+
+// Template is a directory new projects are created from. Files ending in
+// ".tmpl" are rendered with text/template and lose the suffix, other files
+// are copied as is. File and directory names are always rendered, so
+// "cmd/{{.Name}}/main.go.tmpl" works.
+type Template struct {
+	Dir      string            `toml:"-"`
+	Language string            `toml:"language"`
+	Hooks    []string          `toml:"hooks"` // shell commands run in the new project
+	Vars     map[string]string `toml:"vars"`  // defaults, may refer to other data
+}
+
+// TemplatesDir is where named templates live, one directory each.
+func TemplatesDir() (string, error) {
+	configPath, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, "templates"), nil
+}
+
+// ListTemplates returns the names of the templates in TemplatesDir.
+func ListTemplates() ([]string, error) {
+	dir, err := TemplatesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func LoadTemplate(dir string) (*Template, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("template %s not found", dir)
+	}
+
+	t := &Template{Dir: dir}
+	manifest := filepath.Join(dir, templateManifest)
+	if _, err := os.Stat(manifest); err == nil {
+		if _, err := toml.DecodeFile(manifest, t); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", manifest, err)
+		}
+	}
+	return t, nil
+}
+
+// Data returns the template variables for a project: Name, Path and the
+// overrides, then the manifest defaults rendered against those. Defaults
+// may refer to each other in any order.
+func (t *Template) Data(name, path string, overrides map[string]string) (map[string]string, error) {
+	data := map[string]string{"Name": name, "Path": path}
+	for key, value := range overrides {
+		data[key] = value
+	}
+
+	var pending []string
+	for key := range t.Vars {
+		if _, ok := data[key]; !ok {
+			pending = append(pending, key)
+		}
+	}
+	sort.Strings(pending)
+
+	// Render what can be rendered until nothing changes, a default that
+	// still fails refers to a missing variable or to itself
+	for len(pending) > 0 {
+		var failed []string
+		var firstErr error
+		for _, key := range pending {
+			rendered, err := renderString(key, t.Vars[key], data)
+			if err != nil {
+				failed = append(failed, key)
+				firstErr = cmp.Or(firstErr, err)
+				continue
+			}
+			data[key] = rendered
+		}
+		if len(failed) == len(pending) {
+			return nil, firstErr
+		}
+		pending = failed
+	}
+	return data, nil
+}
+
+// Render creates target from the template. target must not exist or be empty.
+func (t *Template) Render(target string, data map[string]string) error {
+	if entries, err := os.ReadDir(target); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", target)
+	}
+
+	return filepath.WalkDir(t.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(t.Dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == templateManifest || d.Name() == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err = renderString(rel, rel, data)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(target, strings.TrimSuffix(rel, ".tmpl"))
+
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(rel, ".tmpl") {
+			rendered, err := renderString(rel, string(content), data)
+			if err != nil {
+				return err
+			}
+			content = []byte(rendered)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dest, content, info.Mode().Perm())
+	})
+}
+
+// HookCommands returns the post-create hooks rendered with data. Values are
+// shell-quoted, so hooks must not quote them again.
+func (t *Template) HookCommands(data map[string]string) ([]string, error) {
+	quoted := make(map[string]string, len(data))
+	for key, value := range data {
+		quoted[key] = ShellQuote(value)
+	}
+
+	commands := make([]string, len(t.Hooks))
+	for i, hook := range t.Hooks {
+		command, err := renderString("hook", hook, quoted)
+		if err != nil {
+			return nil, err
+		}
+		commands[i] = command
+	}
+	return commands, nil
+}
+
+func renderString(name, text string, data map[string]string) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
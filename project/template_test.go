@@ -0,0 +1,65 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateData(t *testing.T) {
+	tests := []struct {
+		name      string
+		vars      map[string]string
+		overrides map[string]string
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name: "defaults refer to each other",
+			vars: map[string]string{"a": "{{.b}}-app", "b": "{{.c}}", "c": "{{.Name}}"},
+			want: map[string]string{"Name": "svc", "Path": "/src/svc", "a": "svc-app", "b": "svc", "c": "svc"},
+		},
+		{
+			name:      "defaults see overrides",
+			vars:      map[string]string{"module": "example.com/{{.owner}}/{{.Name}}", "owner": "acme"},
+			overrides: map[string]string{"owner": "me"},
+			want:      map[string]string{"Name": "svc", "Path": "/src/svc", "module": "example.com/me/svc", "owner": "me"},
+		},
+		{
+			name:    "missing variable",
+			vars:    map[string]string{"a": "{{.nope}}"},
+			wantErr: true,
+		},
+		{
+			name:    "cycle",
+			vars:    map[string]string{"a": "{{.b}}", "b": "{{.a}}"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Map order is random, a single pass could pass by luck
+			for range 20 {
+				got, err := (&Template{Vars: tt.vars}).Data("svc", "/src/svc", tt.overrides)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Data() error = %v, want error %v", err, tt.wantErr)
+				}
+				if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("Data() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateHookCommandsQuote(t *testing.T) {
+	tmpl := &Template{Hooks: []string{"git init {{.Path}} && echo {{.Name}}"}}
+	got, err := tmpl.HookCommands(map[string]string{"Name": "it's; rm -rf ~", "Path": "/src/my app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `git init '/src/my app' && echo 'it'\''s; rm -rf ~'`
+	if got[0] != want {
+		t.Errorf("HookCommands() = %s, want %s", got[0], want)
+	}
+}
@@ -4,27 +4,146 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"github.com/BurntSushi/toml"
+	"slices"
+	"strings"
+	"time"
 )
 
 type Project struct {
-	Name     string `toml:"name"`
-	Path     string `toml:"path"`
-	Language string `toml:"language"`
+	Name          string   `toml:"name" json:"name"`
+	Path          string   `toml:"path" json:"path"`
+	Language      string   `toml:"language" json:"language"`
+	Pinned        bool     `toml:"pinned,omitempty" json:"pinned,omitempty"`
+	Archived      bool     `toml:"archived,omitempty" json:"archived,omitempty"`
+	Remote        string   `toml:"remote,omitempty" json:"remote,omitempty"`
+	DefaultBranch string   `toml:"default_branch,omitempty" json:"default_branch,omitempty"`
+	Tags          []string `toml:"tags,omitempty" json:"tags,omitempty"`
+	Source        string   `toml:"source,omitempty" json:"source,omitempty"` // registry file the project was imported from
+	Parent        string   `toml:"parent,omitempty" json:"parent,omitempty"` // project this is a git worktree of
+	Branch        string   `toml:"branch,omitempty" json:"branch,omitempty"` // branch checked out in the worktree
+
+	EnvFile    string            `toml:"env_file,omitempty" json:"env_file,omitempty"` // dotenv file, relative to the project
+	Env        map[string]string `toml:"env,omitempty" json:"env,omitempty"`
+	Toolchains map[string]string `toml:"toolchains,omitempty" json:"toolchains,omitempty"` // tool to version, over .tool-versions and mise.toml
+
+	storedPath string         // Path as written in projects.toml, before expansion
+	extra      map[string]any // keys unknown to this build, written back on save
+}
+
+// storagePath returns the form of Path to write to projects.toml. A path
+// loaded as "$WORK/api" keeps that form while it still points to the same
+// place; anything else under the home directory is stored as "~/...".
+func (p Project) storagePath() string {
+	if p.storedPath != "" && ExpandPath(p.storedPath) == p.Path {
+		return p.storedPath
+	}
+	return CompactPath(p.Path)
+}
+
+func (p *Project) expandPath() {
+	p.storedPath = p.Path
+	if p.Path != "" {
+		p.Path = ExpandPath(p.Path)
+	}
+}
+
+// TrashedProject is a deleted project kept around so it can be restored.
+type TrashedProject struct {
+	Project
+	DeletedAt time.Time `toml:"deleted_at"`
+	Index     int       `toml:"index"` // position in the project list when deleted
 }
 
 type Config struct {
-	Projects []Project `toml:"projects"`
+	Version  int              `toml:"version"`
+	Projects []Project        `toml:"projects"`
+	Trash    []TrashedProject `toml:"trash,omitempty"`
+	Imports  []string         `toml:"imports,omitempty"` // registries applied again by sync
+
+	extra    map[string]any // top-level keys unknown to this build
+	file     string         // file the config was read from, for error positions
+	source   []string       // lines of that file
+	problems error          // from Validate on load, see Problems
+	invalid  map[int]string // problems by index in Projects
 }
 
-func LoadConfig() (*Config, error) {
+// Problems returns what Validate found when the config was loaded. Such a
+// config can be read and fixed but is refused on save.
+func (c *Config) Problems() error {
+	return c.problems
+}
+
+// Active returns the projects that are not archived. Bulk operations should
+// work on this set rather than on Projects directly.
+func (c *Config) Active() []Project {
+	var active []Project
+	for _, p := range c.Projects {
+		if !p.Archived {
+			active = append(active, p)
+		}
+	}
+	return active
+}
+
+// Find returns the index of the project with the given name.
+func (c *Config) Find(name string) (int, bool) {
+	for i, p := range c.Projects {
+		if p.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// Remove deletes the project at idx. Its worktrees stay listed on their own.
+func (c *Config) Remove(idx int) {
+	name := c.Projects[idx].Name
+	c.Projects = slices.Delete(c.Projects, idx, idx+1)
+	for i := range c.Projects {
+		if c.Projects[i].Parent == name {
+			c.Projects[i].Parent = ""
+		}
+	}
+}
+
+// MoveToTrash removes the project at idx and records it in the trash.
+func (c *Config) MoveToTrash(idx int) {
+	c.Trash = append(c.Trash, TrashedProject{Project: c.Projects[idx], DeletedAt: time.Now(), Index: idx})
+	c.Remove(idx)
+}
+
+// RestoreFromTrash moves the most recently trashed project with the given
+// name back to where it was in the project list, along with the worktrees
+// it had.
+func (c *Config) RestoreFromTrash(name string) (Project, error) {
+	if _, exists := c.Find(name); exists {
+		return Project{}, fmt.Errorf("project %q already exists", name)
+	}
+	for i := len(c.Trash) - 1; i >= 0; i-- {
+		if c.Trash[i].Name == name {
+			p, at := c.Trash[i].Project, min(max(c.Trash[i].Index, 0), len(c.Projects))
+			c.Trash = append(c.Trash[:i], c.Trash[i+1:]...)
+			c.Projects = slices.Insert(c.Projects, at, p)
+			for i, w := range c.Projects {
+				if main, ok := MainWorktree(w.Path); ok && w.Parent == "" && main == p.Path {
+					c.Projects[i].Parent = p.Name
+				}
+			}
+			return p, nil
+		}
+	}
+	return Project{}, fmt.Errorf("project %q not found in trash", name)
+}
+
+// ConfigDir returns the directory holding projects.toml and config.toml,
+// creating it if needed.
+func ConfigDir() (string, error) {
 
 	home, err := os.UserHomeDir()
 
 	if err != nil {
 
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 
 	}
 
@@ -34,77 +153,196 @@ func LoadConfig() (*Config, error) {
 	}
 	configPath := filepath.Join(configDir, "asap-project-manager")
 	if err := os.MkdirAll(configPath, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create config directory: %w", err)
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return configPath, nil
+
+}
+
+// ConfigFile returns the projects file of the active profile.
+func ConfigFile() (string, error) {
+	return profilePath(ActiveProfile())
+}
+
+// LoadConfig loads the projects of the active profile.
+func LoadConfig() (*Config, error) {
+	return LoadProfile(ActiveProfile())
+}
+
+// SaveConfig saves the projects of the active profile.
+func SaveConfig(config *Config) error {
+	return SaveProfile(ActiveProfile(), config)
+}
+
+func LoadProfile(profile string) (*Config, error) {
+
+	filePath, err := profilePath(profile)
+
+	if err != nil {
+
+		return nil, err
+
 	}
-	filePath := filepath.Join(configPath, "projects.toml")
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
 
 		return &Config{Projects: []Project{}}, nil
 
 	}
 
-	var config Config
+	config, cached := cachedConfig(profile, filePath, info)
+	if !cached {
+
+		if config, err = DecodeConfigFile(filePath); err != nil {
 
-	if _, err := toml.DecodeFile(filePath, &config); err != nil {
+			return nil, err
 
-		return nil, fmt.Errorf("failed to decode TOML file: %w", err)
+		}
+
+		// Validate before expansion turns an empty path into the home
+		// directory. A broken entry must not keep list and doctor from
+		// working, so only saving fails.
+		config.problems = config.Validate()
+		for i, p := range config.Projects {
+			if err := p.Validate(); err != nil {
+				if config.invalid == nil {
+					config.invalid = map[int]string{}
+				}
+				config.invalid[i] = err.Error()
+			}
+		}
+
+		if config.problems == nil {
+			storeConfigCache(profile, info, config)
+		}
 
 	}
 
 	for i := range config.Projects {
-		if !filepath.IsAbs(config.Projects[i].Path) {
-			config.Projects[i].Path = filepath.Join(home, config.Projects[i].Path)
-		}
+		config.Projects[i].expandPath()
+	}
+	for i := range config.Trash {
+		config.Trash[i].expandPath()
 	}
 
-	return &config, nil
+	return config, nil
 
 }
 
-func SaveConfig(config *Config) error {
+// DecodeConfigFile reads a projects file as written, without expanding
+// paths. Older schema versions are migrated.
+func DecodeConfigFile(filePath string) (*Config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-	home, err := os.UserHomeDir()
+	config, err := decodeConfig(data)
+	if err != nil {
+		return nil, parseError(filePath, err)
+	}
+	config.file = filePath
+	config.source = strings.Split(string(data), "\n")
+
+	return config, nil
+}
+
+func SaveProfile(profile string, config *Config) error {
+
+	filePath, err := profilePath(profile)
 
 	if err != nil {
 
-		return fmt.Errorf("failed to get user home directory: %w", err)
+		return err
 
 	}
 
-	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 
-	if configDir == "" {
+		return fmt.Errorf("failed to create profile directory: %w", err)
 
-		configDir = filepath.Join(home, ".config")
+	}
+
+	if err := checkWritable(filePath); err != nil {
+
+		return err
 
 	}
 
-	configPath := filepath.Join(configDir, "asap-project-manager")
+	// Store portable paths without touching the caller's copy. Positions in
+	// the old file say nothing about the new content.
+	stored := *config
+	stored.Version = SchemaVersion
+	stored.file, stored.source = "", nil
 
-	if err := os.MkdirAll(configPath, 0755); err != nil {
+	if err := stored.Validate(); err != nil {
 
-		return fmt.Errorf("failed to create config directory: %w", err)
+		return err
 
 	}
 
-	filePath := filepath.Join(configPath, "projects.toml")
+	stored.Projects = slices.Clone(config.Projects)
+	stored.Trash = slices.Clone(config.Trash)
+	for i := range stored.Projects {
+		stored.Projects[i].Path = stored.Projects[i].storagePath()
+	}
+	for i := range stored.Trash {
+		stored.Trash[i].Path = stored.Trash[i].storagePath()
+	}
 
-	file, err := os.Create(filePath)
+	data, err := encodeConfig(&stored)
 
 	if err != nil {
 
-		return fmt.Errorf("failed to create config file: %w", err)
+		return fmt.Errorf("failed to encode config to TOML: %w", err)
 
 	}
 
-	defer func() { _ = file.Close() }()
+	// Readers do not take the lock, replace the file rather than rewrite it.
+	// A symlinked file, e.g. from a dotfiles repository, stays a symlink.
+	if target, err := filepath.EvalSymlinks(filePath); err == nil {
 
-	encoder := toml.NewEncoder(file)
+		filePath = target
 
-	if err := encoder.Encode(config); err != nil {
+	}
 
-		return fmt.Errorf("failed to encode config to TOML: %w", err)
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".projects-*")
+
+	if err != nil {
+
+		return fmt.Errorf("failed to write config file: %w", err)
+
+	}
+
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+
+		_ = tmp.Close()
+
+		return fmt.Errorf("failed to write config file: %w", err)
+
+	}
+
+	if err := tmp.Chmod(0644); err != nil {
+
+		_ = tmp.Close()
+
+		return fmt.Errorf("failed to write config file: %w", err)
+
+	}
+
+	if err := tmp.Close(); err != nil {
+
+		return fmt.Errorf("failed to write config file: %w", err)
+
+	}
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+
+		return fmt.Errorf("failed to write config file: %w", err)
 
 	}
 
@@ -112,20 +350,50 @@ func SaveConfig(config *Config) error {
 
 }
 
-func GuessLanguage(path string) []string {
-	var languages []string
+// languageMarkers maps a file found in a project root to the language it
+// indicates.
+var languageMarkers = map[string]string{
+	"go.mod":           "go",
+	"Cargo.toml":       "rust",
+	"package.json":     "javascript",
+	"requirements.txt": "python",
+	"pom.xml":          "java",
+	"build.gradle":     "java", // Gradle can be used for Java
+	"Makefile":         "c",
+}
 
-	checks := map[string]string{
-		"go.mod":           "go",
-		"Cargo.toml":       "rust",
-		"package.json":     "javascript",
-		"requirements.txt": "python",
-		"pom.xml":          "java",
-		"build.gradle":     "java", // Gradle can be used for Java
-		"Makefile":         "c",
+// KnownLanguage reports whether GuessLanguage is able to detect lang.
+func KnownLanguage(lang string) bool {
+	if lang == "lua" {
+		return true
+	}
+	for _, l := range languageMarkers {
+		if l == lang {
+			return true
+		}
 	}
+	return false
+}
+
+// GuessLanguage returns the languages whose marker files are in the project
+// root. Results are cached until the directory itself changes.
+func GuessLanguage(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return guessLanguage(path)
+	}
+	if languages, ok := cachedDetection(path, info); ok {
+		return languages
+	}
+	languages := guessLanguage(path)
+	storeDetection(path, info, languages)
+	return languages
+}
+
+func guessLanguage(path string) []string {
+	var languages []string
 
-	for file, lang := range checks {
+	for file, lang := range languageMarkers {
 		if _, err := os.Stat(filepath.Join(path, file)); err == nil {
 			// Avoid duplicates
 			found := false
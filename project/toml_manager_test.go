@@ -0,0 +1,54 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigRestoreFromTrash(t *testing.T) {
+	names := func(c *Config) []string {
+		var names []string
+		for _, p := range c.Projects {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name    string
+		trash   []int // indices moved to the trash, in order
+		restore []string
+		want    []string
+	}{
+		{"first", []int{0}, []string{"a"}, []string{"a", "b", "c", "d"}},
+		{"middle", []int{2}, []string{"c"}, []string{"a", "b", "c", "d"}},
+		{"last", []int{3}, []string{"d"}, []string{"a", "b", "c", "d"}},
+		{"in reverse order", []int{1, 1}, []string{"c", "b"}, []string{"a", "b", "c", "d"}},
+		{"list shrank", []int{3, 0, 0}, []string{"d"}, []string{"c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{Projects: []Project{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}}
+			for _, i := range tt.trash {
+				c.MoveToTrash(i)
+			}
+			for _, name := range tt.restore {
+				if _, err := c.RestoreFromTrash(name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := names(c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("projects = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigRemoveDetachesWorktrees(t *testing.T) {
+	c := &Config{Projects: []Project{{Name: "api"}, {Name: "api@dev", Parent: "api", Branch: "dev"}, {Name: "web"}}}
+	c.MoveToTrash(0)
+	if p := c.Projects[0]; p.Parent != "" || p.Branch != "dev" {
+		t.Errorf("worktree = %+v, want no parent and its branch", p)
+	}
+}
@@ -0,0 +1,279 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ValidationError is one problem in a projects file. Line and Column are
+// zero when the position is unknown.
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Field   string
+	Problem string
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File + ":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, "%d:", e.Column)
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if e.Field != "" {
+		b.WriteString(e.Field + ": ")
+	}
+	b.WriteString(e.Problem)
+	return b.String()
+}
+
+// ValidationErrors lists every problem found, in file order.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the fields of a single project.
+func (p Project) Validate() error {
+	var errs ValidationErrors
+	if strings.TrimSpace(p.Name) == "" {
+		errs = append(errs, &ValidationError{Field: "name", Problem: "must not be empty"})
+	}
+	if strings.TrimSpace(p.Path) == "" {
+		errs = append(errs, &ValidationError{Field: "path", Problem: "must not be empty"})
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Validate checks every project and trash entry, and that project names are
+// unique. When c was loaded from a file, errors point to the line of the
+// offending key.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+
+	check := func(section string, i int, p Project) {
+		var projectErrs ValidationErrors
+		if !errors.As(p.Validate(), &projectErrs) {
+			return
+		}
+		for _, err := range projectErrs {
+			err.File = c.file
+			err.Line, err.Column = c.position(section, i, err.Field)
+			err.Field = fmt.Sprintf("%s[%d].%s", section, i, err.Field)
+			errs = append(errs, err)
+		}
+	}
+	names := map[string]bool{}
+	for i, p := range c.Projects {
+		check("projects", i, p)
+		if names[p.Name] && p.Name != "" {
+			line, col := c.position("projects", i, "name")
+			errs = append(errs, &ValidationError{File: c.file, Line: line, Column: col, Field: fmt.Sprintf("projects[%d].name", i), Problem: fmt.Sprintf("%q is used by another project", p.Name)})
+		}
+		names[p.Name] = true
+	}
+	for i, t := range c.Trash {
+		check("trash", i, t.Project)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(errs, func(a, b *ValidationError) int { return a.Line - b.Line })
+	return errs
+}
+
+// position finds the line and column of key in the i-th [[section]] table
+// of the source file, or of the table header if the key is missing. The
+// empty section stands for the top-level keys.
+func (c *Config) position(section string, i int, key string) (int, int) {
+	header := "[[" + section + "]]"
+	table := -1
+	if section == "" {
+		table = 0
+	}
+	headerLine := 0
+
+	for n, line := range c.source {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if table == i {
+				break // end of the table we were in
+			}
+			if trimmed == header {
+				if table++; table == i {
+					headerLine = n + 1
+				}
+			}
+			continue
+		}
+		if table != i {
+			continue
+		}
+		if name, _, found := strings.Cut(trimmed, "="); found && strings.TrimSpace(name) == key {
+			return n + 1, strings.Index(line, key) + 1
+		}
+	}
+	return headerLine, 0
+}
+
+// checkTypes compares the values in raw with the field types of Config, so
+// that a mismatch can point to its line. The decoder reports those only as
+// text.
+func checkTypes(raw map[string]any, source []string) ValidationErrors {
+	c := &Config{source: source}
+	var errs ValidationErrors
+
+	check := func(section string, i int, fields map[string]reflect.Type, table map[string]any) {
+		for key, value := range table {
+			t, ok := fields[key]
+			if !ok || hasType(t, value) {
+				continue
+			}
+			line, col := c.position(section, i, key)
+			field := key
+			if section != "" {
+				field = fmt.Sprintf("%s[%d].%s", section, i, key)
+			}
+			errs = append(errs, &ValidationError{Line: line, Column: col, Field: field, Problem: "must be " + typeName(t)})
+		}
+	}
+
+	config := tomlFields(reflect.TypeFor[Config]())
+	check("", 0, config, raw)
+	for _, section := range []string{"projects", "trash"} {
+		tables, ok := raw[section].([]map[string]any)
+		if !ok {
+			continue
+		}
+		entry := tomlFields(config[section].Elem())
+		for i, table := range tables {
+			check(section, i, entry, table)
+		}
+	}
+
+	slices.SortFunc(errs, func(a, b *ValidationError) int { return a.Line - b.Line })
+	return errs
+}
+
+// tomlFields maps the toml keys of a struct, including embedded ones, to
+// their types.
+func tomlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for _, f := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if name != "" && f.IsExported() {
+			fields[name] = f.Type
+		}
+	}
+	return fields
+}
+
+// hasType reports whether a decoded TOML value fits a field of type t.
+// Tables and arrays of tables are checked by their own entries.
+func hasType(t reflect.Type, value any) bool {
+	if t == reflect.TypeFor[time.Time]() {
+		_, ok := value.(time.Time)
+		return ok
+	}
+	switch t.Kind() {
+	case reflect.String:
+		_, ok := value.(string)
+		return ok
+	case reflect.Bool:
+		_, ok := value.(bool)
+		return ok
+	case reflect.Int, reflect.Int64:
+		_, ok := value.(int64)
+		return ok
+	case reflect.Map:
+		table, ok := value.(map[string]any)
+		for _, v := range table {
+			if !hasType(t.Elem(), v) {
+				return false
+			}
+		}
+		return ok
+	case reflect.Slice:
+		array, ok := value.([]any)
+		if t.Elem().Kind() == reflect.Struct {
+			_, tables := value.([]map[string]any)
+			return tables || ok && len(array) == 0
+		}
+		for _, v := range array {
+			if !hasType(t.Elem(), v) {
+				return false
+			}
+		}
+		return ok
+	}
+	return true
+}
+
+func typeName(t reflect.Type) string {
+	if t == reflect.TypeFor[time.Time]() {
+		return "a date"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int64:
+		return "an integer"
+	case reflect.Map:
+		return "a table of " + strings.TrimPrefix(strings.TrimPrefix(typeName(t.Elem()), "a "), "an ") + "s"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Struct {
+			return "an array of tables"
+		}
+		return "an array of " + strings.TrimPrefix(strings.TrimPrefix(typeName(t.Elem()), "a "), "an ") + "s"
+	}
+	return t.String()
+}
+
+// parseError converts a TOML syntax error into a ValidationError, and sets
+// the file on the errors of checkTypes.
+func parseError(file string, err error) error {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			e.File = file
+		}
+		return errs
+	}
+	var pe toml.ParseError
+	if !errors.As(err, &pe) {
+		return err
+	}
+	return ValidationErrors{{
+		File:    file,
+		Line:    pe.Position.Line,
+		Column:  pe.Position.Col,
+		Field:   pe.LastKey,
+		Problem: pe.Message,
+	}}
+}
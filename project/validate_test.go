@@ -0,0 +1,121 @@
+package project
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const positionSource = `version = 1
+owner = "team"
+
+[[projects]]
+name = "api"
+path = "/src/api"
+
+[projects.env]
+path = "not the project path"
+
+[[projects]]
+  name = "web"
+
+[[trash]]
+name = "old"
+`
+
+func TestConfigPosition(t *testing.T) {
+	c := &Config{source: strings.Split(positionSource, "\n")}
+
+	tests := []struct {
+		section string
+		i       int
+		key     string
+		line    int
+		column  int
+	}{
+		{"", 0, "owner", 2, 1},
+		{"projects", 0, "path", 6, 1},
+		{"projects", 1, "name", 12, 3},
+		{"projects", 1, "path", 11, 0}, // missing key points to the header
+		{"trash", 0, "name", 15, 1},
+		{"projects", 2, "name", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.section+"."+tt.key, func(t *testing.T) {
+			line, column := c.position(tt.section, tt.i, tt.key)
+			if line != tt.line || column != tt.column {
+				t.Errorf("position(%q, %d, %q) = %d:%d, want %d:%d", tt.section, tt.i, tt.key, line, column, tt.line, tt.column)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		field string
+		line  int
+	}{
+		{"syntax", "version = 1\nprojects = [\n", "", 2},
+		{"string expected", "version = 1\n\n[[projects]]\nname = \"a\"\npath = 5\n", "projects[0].path", 5},
+		{"boolean expected", "version = 1\n\n[[projects]]\nname = \"a\"\npath = \"/a\"\npinned = \"yes\"\n", "projects[0].pinned", 6},
+		{"array of strings expected", "version = 1\n\n[[projects]]\nname = \"a\"\npath = \"/a\"\ntags = [1]\n", "projects[0].tags", 6},
+		{"top-level", "version = \"one\"\n", "version", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeConfig([]byte(tt.data))
+			var errs ValidationErrors
+			if !errors.As(parseError("projects.toml", err), &errs) || len(errs) == 0 {
+				t.Fatalf("got %v, want validation errors", err)
+			}
+			if errs[0].Line != tt.line || tt.field != "" && errs[0].Field != tt.field {
+				t.Errorf("got %v, want %s at line %d", errs[0], tt.field, tt.line)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		projects []Project
+		fields   []string
+	}{
+		{"valid", []Project{{Name: "a", Path: "/a"}, {Name: "b", Path: "/b"}}, nil},
+		{"empty fields", []Project{{Name: " ", Path: ""}}, []string{"projects[0].name", "projects[0].path"}},
+		{"duplicate name", []Project{{Name: "a", Path: "/a"}, {Name: "a", Path: "/b"}}, []string{"projects[1].name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			var errs ValidationErrors
+			if errors.As((&Config{Projects: tt.projects}).Validate(), &errs) {
+				for _, err := range errs {
+					fields = append(fields, err.Field)
+				}
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("errors in %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestConfigValidateFileOrder(t *testing.T) {
+	c := &Config{
+		Projects: []Project{{Name: "a", Path: "/a"}, {Name: "", Path: ""}},
+		source:   strings.Split("[[projects]]\nname = \"a\"\npath = \"/a\"\n\n[[projects]]\npath = \"\"\nname = \"\"\n", "\n"),
+	}
+	var errs ValidationErrors
+	if !errors.As(c.Validate(), &errs) || len(errs) != 2 {
+		t.Fatalf("Validate() = %v, want two errors", c.Validate())
+	}
+	if errs[0].Field != "projects[1].path" || errs[0].Line != 6 || errs[1].Line != 7 {
+		t.Errorf("errors = %v, want path at line 6 before name at line 7", errs)
+	}
+}
@@ -0,0 +1,84 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Worktree is a checkout listed by "git worktree list".
+type Worktree struct {
+	Path   string
+	Branch string // empty for a detached HEAD
+}
+
+// MainWorktree returns the main checkout of the repository if path is a
+// linked worktree.
+func MainWorktree(path string) (string, bool) {
+	dir, ok := gitDir(path)
+	if !ok {
+		return "", false
+	}
+	common := commonGitDir(dir)
+	if common == dir {
+		return "", false
+	}
+	return filepath.Dir(common), true
+}
+
+// Worktrees returns every checkout of the repository at path, the main one
+// first.
+func Worktrees(path string) ([]Worktree, error) {
+	out, err := exec.Command("git", "-C", path, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git worktree list: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	var worktrees []Worktree
+	for _, line := range strings.Split(string(out), "\n") {
+		if wt, ok := strings.CutPrefix(line, "worktree "); ok {
+			worktrees = append(worktrees, Worktree{Path: wt})
+		} else if ref, ok := strings.CutPrefix(line, "branch "); ok && len(worktrees) > 0 {
+			worktrees[len(worktrees)-1].Branch = strings.TrimPrefix(ref, "refs/heads/")
+		}
+	}
+	return worktrees, nil
+}
+
+// AddWorktree checks out branch at path as a worktree of the repository at
+// repoPath. Branches that exist neither locally nor on origin are created.
+func AddWorktree(repoPath, path, branch string) error {
+	args := []string{"-C", repoPath, "worktree", "add", path, branch}
+	local := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run()
+	remote := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch).Run()
+	if local != nil && remote != nil {
+		args = []string{"-C", repoPath, "worktree", "add", "-b", branch, path}
+	}
+
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git worktree add: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// RepairWorktrees fixes the links between the checkout at path and the
+// worktrees at the given paths after one of them was moved.
+func RepairWorktrees(path string, worktrees ...string) error {
+	args := append([]string{"-C", path, "worktree", "repair"}, worktrees...)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git worktree repair: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// WorktreePath returns the default location of a worktree for branch: next
+// to the main checkout, named after it and the branch.
+func WorktreePath(repoPath, branch string) string {
+	return repoPath + "-" + strings.ReplaceAll(branch, "/", "-")
+}
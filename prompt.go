@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// defaultPromptFormat is used unless --format is given. Placeholders that
+// are empty for the current directory disappear with their spacing.
+const defaultPromptFormat = "{name}{/module} {language} {branch}"
+
+// branchAt returns the branch checked out in the repository holding dir.
+func branchAt(dir string) string {
+	for {
+		if project.IsGitRepo(dir) {
+			return project.GitBranch(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func runPrompt(args []string) error {
+	fs := flag.NewFlagSet("prompt", flag.ExitOnError)
+	format := fs.String("format", defaultPromptFormat, "segment with {name}, {module}, {/module}, {language}, {branch} and {path}")
+	dir := fs.String("dir", "", "directory to describe (default: working directory), e.g. #{pane_current_path} in tmux")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		*dir = cwd
+	}
+
+	// A running daemon answers without reading anything from disk
+	var name, path, language, module string
+	if resp, err := project.QueryDaemon(project.ActiveProfile(), project.DaemonRequest{Method: project.DaemonLookup, Dir: *dir}); err == nil {
+		name, path, language, module = resp.Name, resp.Path, resp.Language, resp.Module
+	} else {
+		index, err := project.LoadIndex(project.ActiveProfile())
+		if err != nil {
+			return err
+		}
+		if entry, ok := index.Lookup(*dir); ok {
+			name, path, language, module = entry.Name, entry.Path, entry.Language, entry.Module(*dir)
+		}
+	}
+
+	// Outside a project the segment is empty
+	if name == "" {
+		return nil
+	}
+
+	slashModule := ""
+	if module != "" {
+		slashModule = "/" + module
+	}
+
+	segment := strings.NewReplacer(
+		"{name}", name,
+		"{module}", module,
+		"{/module}", slashModule,
+		"{language}", language,
+		"{branch}", branchAt(*dir),
+		"{path}", project.CompactPath(path),
+	).Replace(*format)
+	fmt.Println(strings.Join(strings.Fields(segment), " "))
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// recoveryModel is shown instead of exiting when the projects file cannot be
+// loaded. It lists the problems and lets the user fix the file in the editor.
+type recoveryModel struct {
+	err    error
+	file   string
+	editor string
+	config *project.Config // set once the file loads again
+}
+
+type editorFinishedMsg struct {
+	err error
+}
+
+func initialRecoveryModel(err error) recoveryModel {
+	file, _ := project.ConfigFile()
+	editor := project.DefaultSettings().Editor
+	if settings, err := project.LoadSettings(); err == nil {
+		editor = settings.Editor
+	}
+	return recoveryModel{err: err, file: file, editor: editor}
+}
+
+func (m recoveryModel) Init() tea.Cmd {
+	return nil
+}
+
+// retry loads the config again and quits once it is valid.
+func (m recoveryModel) retry() (tea.Model, tea.Cmd) {
+	config, err := project.LoadConfig()
+	if err == nil {
+		err = config.Problems()
+	}
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.config = config
+	return m, tea.Quit
+}
+
+func (m recoveryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("editor failed: %w", msg.err)
+			return m, nil
+		}
+		return m.retry()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "r":
+			return m.retry()
+		case "e", "enter":
+			editor := strings.Fields(m.editor)
+			if len(editor) == 0 || m.file == "" {
+				return m, nil
+			}
+			cmd := exec.Command(editor[0], append(editor[1:], m.editorArgs()...)...)
+			return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+				return editorFinishedMsg{err}
+			})
+		}
+	}
+	return m, nil
+}
+
+// lineEditors take "+N" to open a file at line N.
+var lineEditors = []string{"vi", "vim", "nvim", "gvim", "view", "nano", "emacs", "micro", "kak", "hx"}
+
+// editorArgs opens the file at the first problem for editors that support it.
+func (m recoveryModel) editorArgs() []string {
+	var errs project.ValidationErrors
+	editor := strings.Fields(m.editor)
+	if errors.As(m.err, &errs) && len(errs) > 0 && errs[0].Line > 0 && len(editor) > 0 && slices.Contains(lineEditors, filepath.Base(editor[0])) {
+		return []string{fmt.Sprintf("+%d", errs[0].Line), m.file}
+	}
+	return []string{m.file}
+}
+
+func (m recoveryModel) View() string {
+	s := "Could not load projects\n\n"
+
+	var errs project.ValidationErrors
+	if errors.As(m.err, &errs) {
+		for _, err := range errs {
+			s += "  " + err.Error() + "\n"
+		}
+	} else {
+		s += "  " + m.err.Error() + "\n"
+	}
+
+	s += fmt.Sprintf("\nPress 'e' to open %s in %s, 'r' to retry, 'q' to quit", m.file, m.editor)
+	return s
+}
+
+// recoverConfig runs the recovery screen for err and returns the config once
+// it loads, or nil if the user gave up.
+func recoverConfig(err error) *project.Config {
+	m, runErr := tea.NewProgram(initialRecoveryModel(err)).Run()
+	if runErr != nil {
+		return nil
+	}
+	return m.(recoveryModel).config
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func runMove(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: asap-pm mv <name> <newpath>")
+	}
+	name := args[0]
+	newPath := resolvePath(args[1])
+
+	unlock, err := project.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	idx, ok := config.Find(name)
+	if !ok {
+		return fmt.Errorf("project %q not found", name)
+	}
+	p := &config.Projects[idx]
+
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("%s already exists", newPath)
+	}
+
+	// Saving refuses an invalid config, find out before anything moves
+	if err := config.Problems(); err != nil {
+		return err
+	}
+	oldPath := p.Path
+	p.Path = newPath
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		if errors.Is(err, syscall.EXDEV) {
+			return fmt.Errorf("%s is on a different filesystem, move it manually and run 'asap-pm relocate'", newPath)
+		}
+		return fmt.Errorf("failed to move project: %w", err)
+	}
+
+	if err := project.SaveConfig(config); err != nil {
+		if rerr := os.Rename(newPath, oldPath); rerr != nil {
+			return fmt.Errorf("failed to save config: %w (the project is left at %s: %v)", err, newPath, rerr)
+		}
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	unlock()
+	fmt.Printf("Moved %s to %s\n", oldPath, newPath)
+
+	// git links a repository and its worktrees by absolute path
+	var worktrees []string
+	for _, w := range config.Projects {
+		if w.Parent == name {
+			worktrees = append(worktrees, w.Path)
+		}
+	}
+	if len(worktrees) > 0 || p.Parent != "" {
+		if err := project.RepairWorktrees(newPath, worktrees...); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	runHooks(project.HookEdit, *p)
+
+	// The structure file records absolute paths, regenerate it
+	if _, err := os.Stat(project.StructurePath(newPath)); err == nil && p.Language == "java" {
+		fmt.Println("Detecting Java project structure...")
+		if err := detectStructure(*p); err != nil {
+			fmt.Printf("Warning: Failed to run Java project structure detector: %v\n", err)
+		}
+	}
+	return nil
+}
+
+func runRelocate(args []string) error {
+	fs := flag.NewFlagSet("relocate", flag.ExitOnError)
+	from := fs.String("from", "", "old path prefix")
+	to := fs.String("to", "", "new path prefix")
+	byRemote := fs.Bool("by-remote", false, "find missing projects by their git remote")
+	root := fs.String("root", "", "directory to search with --by-remote (default: scan_roots setting)")
+	dryRun := fs.Bool("dry-run", false, "show changes without saving them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *byRemote == (*from != "" || *to != "") || (!*byRemote && (*from == "" || *to == "")) {
+		return fmt.Errorf("usage: asap-pm relocate --from <old> --to <new> | --by-remote [--root <dir>]")
+	}
+
+	// Searching can take a while, the lock is only taken to save
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	settings, err := project.LoadSettings()
+	if err != nil {
+		return err
+	}
+	roots := settings.Roots()
+	if *root != "" {
+		roots = []string{resolvePath(*root)}
+	}
+	oldPrefix, newPrefix := resolvePath(*from), resolvePath(*to)
+
+	moves := map[string]string{} // project name to new path
+	for i := range config.Projects {
+		p := &config.Projects[i]
+		if p.Archived {
+			continue
+		}
+
+		var newPath string
+		if *byRemote {
+			if _, err := os.Stat(p.Path); err == nil || p.Remote == "" {
+				continue
+			}
+			found, ok := project.FindRepoByRemote(roots, settings.Ignore, p.Remote, relocateSearchDepth)
+			if !ok {
+				fmt.Printf("%s: not found\n", p.Name)
+				continue
+			}
+			newPath = found
+		} else {
+			rest, ok := strings.CutPrefix(p.Path, oldPrefix)
+			if !ok || (rest != "" && !strings.HasPrefix(rest, string(filepath.Separator))) {
+				continue
+			}
+			newPath = newPrefix + rest
+		}
+
+		fmt.Printf("%s: %s -> %s\n", p.Name, p.Path, newPath)
+		moves[p.Name] = newPath
+	}
+
+	if len(moves) == 0 {
+		fmt.Println("No projects to relocate.")
+		return nil
+	}
+	if *dryRun {
+		return nil
+	}
+
+	var changed []project.Project
+	err = project.UpdateProfile(project.ActiveProfile(), func(config *project.Config) error {
+		for name, newPath := range moves {
+			if idx, ok := config.Find(name); ok {
+				config.Projects[idx].Path = newPath
+				changed = append(changed, config.Projects[idx])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Printf("Relocated %d project(s).\n", len(changed))
+	for _, p := range changed {
+		runHooks(project.HookEdit, p)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// taskCmd builds the command running the named task of p in the project
+// directory with the project environment, attached to the terminal. With
+// modules, the task only runs in those modules of a Gradle or Maven build.
+func taskCmd(p project.Project, name string, modules []project.Module) (*exec.Cmd, error) {
+	tasks, err := project.Tasks(p)
+	if err != nil {
+		return nil, err
+	}
+	command, ok := tasks[name]
+	if !ok {
+		return nil, fmt.Errorf("project %q has no task %q", p.Name, name)
+	}
+
+	if len(modules) > 0 {
+		structure, err := project.LoadStructure(p.Path)
+		if err != nil {
+			return nil, err
+		}
+		if command, err = structure.ModuleCommand(command, modules); err != nil {
+			return nil, err
+		}
+	}
+
+	env, err := project.Environ(p)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = p.Path
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd, nil
+}
+
+func printTasks(p project.Project) error {
+	tasks, err := project.Tasks(p)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Printf("No tasks for %s, add them to %s\n", p.Name, project.StructurePath(p.Path))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, name := range project.TaskNames(tasks) {
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", name, tasks[name])
+	}
+	return w.Flush()
+}
+
+// selectModules returns the modules named in names, a comma separated list,
+// or those changed since base when changed is set.
+func selectModules(p project.Project, names string, changed bool, base string) ([]project.Module, error) {
+	if names == "" && !changed {
+		return nil, nil
+	}
+
+	structure, err := project.LoadStructure(p.Path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no modules known for %q, run the structure detector first", p.Name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		if base == "" {
+			base = project.DefaultBase(p.Path)
+		}
+		return structure.ChangedModules(p.Path, base)
+	}
+
+	var modules []project.Module
+	for _, name := range strings.Split(names, ",") {
+		m, ok := structure.FindModule(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("module %q not found in %s", name, project.StructurePath(p.Path))
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+func runRun(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	module := fs.String("module", "", "run only in these modules, comma separated")
+	changed := fs.Bool("changed", false, "run only in modules changed since --base")
+	base := fs.String("base", "", "branch to compare with --changed (default: origin/HEAD or main)")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 || len(args) > 2 || (*module != "" && *changed) {
+		return errors.New("usage: asap-pm run <name> [task] [--module <a,b> | --changed [--base <branch>]]")
+	}
+
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	idx, ok := config.Find(args[0])
+	if !ok {
+		return fmt.Errorf("project %q not found", args[0])
+	}
+	p := config.Projects[idx]
+
+	// Without a task, show what can be run
+	if len(args) == 1 {
+		return printTasks(p)
+	}
+
+	modules, err := selectModules(p, *module, *changed, *base)
+	if err != nil {
+		return err
+	}
+	if *changed && len(modules) == 0 {
+		fmt.Println("No modules changed.")
+		return nil
+	}
+
+	cmd, err := taskCmd(p, args[1], modules)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("$ %s\n", cmd.Args[2])
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitCode(exitErr.ExitCode())
+		}
+		return err
+	}
+	return nil
+}
+
+// taskPalette lists the tasks of a project in the manage view. Multi-module
+// builds also get a module picker.
+type taskPalette struct {
+	item         projectItem
+	names        []string
+	tasks        map[string]string
+	cursor       int
+	modules      []project.Module
+	moduleCursor int // 0 is every module, 1 the changed ones, then modules
+	pickModule   bool
+	base         string // branch changed modules are compared with
+}
+
+// Module picker entries before the modules themselves
+const (
+	moduleAll = iota
+	moduleChanged
+	moduleFirst
+)
+
+// taskFinishedMsg is sent when a task started from the TUI exits.
+type taskFinishedMsg struct {
+	name string
+	err  error
+}
+
+func (m manageProjectsModel) openPalette(item projectItem) (tea.Model, tea.Cmd) {
+	tasks, err := project.Tasks(item.project)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	if len(tasks) == 0 {
+		m.status = fmt.Sprintf("No tasks for '%s', add them to %s", item.project.Name, project.StructurePath(item.project.Path))
+		return m, nil
+	}
+	m.palette = &taskPalette{item: item, names: project.TaskNames(tasks), tasks: tasks}
+	if structure, err := project.LoadStructure(item.project.Path); err == nil && len(structure.Modules) > 1 {
+		m.palette.modules = structure.Modules
+		m.palette.base = project.DefaultBase(item.project.Path)
+	}
+	return m, nil
+}
+
+// selected returns the modules picked in the palette, nil for all of them.
+func (p taskPalette) selected() ([]project.Module, error) {
+	switch p.moduleCursor {
+	case moduleAll:
+		return nil, nil
+	case moduleChanged:
+		structure, err := project.LoadStructure(p.item.project.Path)
+		if err != nil {
+			return nil, err
+		}
+		modules, err := structure.ChangedModules(p.item.project.Path, p.base)
+		if err == nil && len(modules) == 0 {
+			err = errors.New("no modules changed")
+		}
+		return modules, err
+	}
+	return []project.Module{p.modules[p.moduleCursor-moduleFirst]}, nil
+}
+
+func (m manageProjectsModel) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	palette := *m.palette
+	m.palette = &palette
+
+	// Up and down move in the focused list
+	cursor, count := &palette.cursor, len(palette.names)
+	if palette.pickModule {
+		cursor, count = &palette.moduleCursor, len(palette.modules)+moduleFirst
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "t":
+		m.palette = nil
+	case "tab", "shift+tab":
+		palette.pickModule = !palette.pickModule && len(palette.modules) > 0
+	case "up", "k":
+		*cursor = (*cursor - 1 + count) % count
+	case "down", "j":
+		*cursor = (*cursor + 1) % count
+	case "enter":
+		m.palette = nil
+		name := palette.names[palette.cursor]
+		modules, err := palette.selected()
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		cmd, err := taskCmd(palette.item.project, name, modules)
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+			return taskFinishedMsg{name, err}
+		})
+	}
+	return m, nil
+}
+
+func (p taskPalette) View() string {
+	mark := func(selected, focused bool) string {
+		if selected && focused {
+			return ">"
+		} else if selected {
+			return "*"
+		}
+		return " "
+	}
+
+	s := fmt.Sprintf("Tasks of '%s':\n", p.item.project.Name)
+	w := 0
+	for _, name := range p.names {
+		w = max(w, len(name))
+	}
+	for i, name := range p.names {
+		s += fmt.Sprintf("%s %-*s  %s\n", mark(i == p.cursor, !p.pickModule), w, name, p.tasks[name])
+	}
+
+	if len(p.modules) == 0 {
+		return s + "\nEnter to run, Esc to close"
+	}
+
+	s += "\nModules:\n"
+	entries := []string{"all", "changed since " + p.base}
+	for _, module := range p.modules {
+		if module.Path != "" {
+			entries = append(entries, module.Path)
+		} else {
+			entries = append(entries, module.Name)
+		}
+	}
+	for i, entry := range entries {
+		s += fmt.Sprintf("%s %s\n", mark(i == p.moduleCursor, p.pickModule), entry)
+	}
+	return s + "\nTab to switch between tasks and modules, Enter to run, Esc to close"
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcFailed         = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// rpcParams are the parameters of every method, each uses what it needs.
+type rpcParams struct {
+	Name     string          `json:"name"`
+	Archived bool            `json:"archived"` // list: include archived projects
+	Project  json.RawMessage `json:"project"`  // add: the new project, update: the fields to change
+}
+
+// rpcMethods are served by "asap-pm serve". Mutations take the profile lock
+// and are validated on save like every other command.
+var rpcMethods = map[string]func(params rpcParams) (any, error){
+	"list":      rpcList,
+	"get":       rpcGet,
+	"add":       rpcAdd,
+	"update":    rpcUpdate,
+	"remove":    rpcRemove,
+	"detect":    rpcDetect,
+	"structure": rpcStructure,
+}
+
+// serveMu serializes mutations from concurrent clients of this process,
+// the profile lock those of other processes.
+var serveMu sync.Mutex
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	socket := fs.String("socket", "", "unix socket to listen on (default: stdin and stdout)")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// Output of hooks and detectors must not end up in the responses
+	out := os.Stdout
+	os.Stdout = os.Stderr
+
+	if *socket == "" {
+		serveRPC(os.Stdin, out)
+		return nil
+	}
+
+	path := resolvePath(*socket)
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s is already in use", path)
+	}
+	_ = os.Remove(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	defer listener.Close()
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("failed to restrict socket: %w", err)
+	}
+
+	// Closing the listener removes the socket
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		_ = listener.Close()
+	}()
+
+	log.Printf("Serving profile %s on %s", project.ActiveProfile(), path)
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			serveRPC(conn, conn)
+		}()
+	}
+}
+
+// serveRPC answers requests read from r, one JSON object per line, until r
+// is closed.
+func serveRPC(r io.Reader, w io.Writer) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		var req rpcRequest
+		resp := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = &rpcError{rpcParseError, err.Error()}
+		} else {
+			if req.ID == nil {
+				_, _ = callRPC(req)
+				continue
+			}
+			resp.ID = req.ID
+			resp.Result, resp.Error = callRPC(req)
+			if resp.Error == nil && resp.Result == nil {
+				resp.Result = json.RawMessage("null")
+			}
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func callRPC(req rpcRequest) (any, *rpcError) {
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("unknown method %q", req.Method)}
+	}
+
+	var params rpcParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+	}
+
+	result, err := method(params)
+	if err != nil {
+		var rpcErr *rpcError
+		if errors.As(err, &rpcErr) {
+			return nil, rpcErr
+		}
+		return nil, &rpcError{rpcFailed, err.Error()}
+	}
+	return result, nil
+}
+
+// findProject returns the project named in params from a fresh copy of the
+// active profile.
+func findProject(params rpcParams) (project.Project, error) {
+	if params.Name == "" {
+		return project.Project{}, &rpcError{rpcInvalidParams, "name is required"}
+	}
+	config, err := project.LoadConfig()
+	if err != nil {
+		return project.Project{}, err
+	}
+	idx, ok := config.Find(params.Name)
+	if !ok {
+		return project.Project{}, fmt.Errorf("project %q not found", params.Name)
+	}
+	return config.Projects[idx], nil
+}
+
+func rpcList(params rpcParams) (any, error) {
+	config, err := project.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	projects := config.Active()
+	if params.Archived {
+		projects = config.Projects
+	}
+	if projects == nil {
+		projects = []project.Project{}
+	}
+	return projects, nil
+}
+
+func rpcGet(params rpcParams) (any, error) {
+	return findProject(params)
+}
+
+// rpcAdd registers a project the way "asap-pm <name> <path>" does. The
+// language is detected unless given, and must be given when it cannot be.
+func rpcAdd(params rpcParams) (any, error) {
+	var p project.Project
+	if err := json.Unmarshal(params.Project, &p); err != nil {
+		return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("invalid project: %v", err)}
+	}
+	if p.Name == "" || p.Path == "" {
+		return nil, &rpcError{rpcInvalidParams, "project needs a name and a path"}
+	}
+	p.Path = resolvePath(p.Path)
+	if p.Language == "" {
+		if languages := project.GuessLanguage(p.Path); len(languages) > 0 {
+			p.Language = languages[0]
+		}
+		if p.Language == "" {
+			return nil, &rpcError{rpcInvalidParams, "could not guess language, please specify"}
+		}
+	}
+
+	serveMu.Lock()
+	defer serveMu.Unlock()
+
+	if err := addProject(p); err != nil {
+		return nil, err
+	}
+	return findProject(rpcParams{Name: p.Name})
+}
+
+// rpcUpdate changes the fields present in params.Project. A rename moves the
+// worktrees of the project along, like editing it in the TUI.
+func rpcUpdate(params rpcParams) (any, error) {
+	if params.Name == "" || len(params.Project) == 0 {
+		return nil, &rpcError{rpcInvalidParams, "name and project are required"}
+	}
+
+	serveMu.Lock()
+	defer serveMu.Unlock()
+
+	var updated project.Project
+	err := project.UpdateProfile(project.ActiveProfile(), func(config *project.Config) error {
+		idx, ok := config.Find(params.Name)
+		if !ok {
+			return fmt.Errorf("project %q not found", params.Name)
+		}
+		updated = config.Projects[idx]
+		if err := json.Unmarshal(params.Project, &updated); err != nil {
+			return &rpcError{rpcInvalidParams, fmt.Sprintf("invalid project: %v", err)}
+		}
+		if updated.Path != "" {
+			updated.Path = resolvePath(updated.Path)
+		}
+		return applyUpdate(config, params.Name, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	runHooks(project.HookEdit, updated)
+	return findProject(rpcParams{Name: updated.Name})
+}
+
+// rpcRemove moves a project to the trash, its files are left alone.
+func rpcRemove(params rpcParams) (any, error) {
+	if params.Name == "" {
+		return nil, &rpcError{rpcInvalidParams, "name is required"}
+	}
+
+	serveMu.Lock()
+	defer serveMu.Unlock()
+
+	var removed project.Project
+	err := project.UpdateProfile(project.ActiveProfile(), func(config *project.Config) error {
+		idx, ok := config.Find(params.Name)
+		if !ok {
+			return fmt.Errorf("project %q not found", params.Name)
+		}
+		removed = config.Projects[idx]
+		config.MoveToTrash(idx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	runHooks(project.HookRemove, removed)
+	return removed, nil
+}
+
+// rpcDetect detects the language of a project again and, for Java
+// projects, its structure.
+func rpcDetect(params rpcParams) (any, error) {
+	if params.Name == "" {
+		return nil, &rpcError{rpcInvalidParams, "name is required"}
+	}
+
+	serveMu.Lock()
+	defer serveMu.Unlock()
+
+	var p project.Project
+	changed := false
+	err := project.UpdateProfile(project.ActiveProfile(), func(config *project.Config) error {
+		idx, ok := config.Find(params.Name)
+		if !ok {
+			return fmt.Errorf("project %q not found", params.Name)
+		}
+		p = config.Projects[idx]
+		known := p.Language == "" || project.KnownLanguage(p.Language)
+		if detected := project.GuessLanguage(p.Path); known && len(detected) > 0 && !slices.Contains(detected, p.Language) {
+			config.Projects[idx].Language = detected[0]
+			p, changed = config.Projects[idx], true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		runHooks(project.HookEdit, p)
+	}
+
+	if p.Language == "java" {
+		if err := detectStructure(p); err != nil {
+			return nil, fmt.Errorf("failed to run Java project structure detector: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// rpcStructure returns .asap/project.toml of a project, null when it has
+// not been generated.
+func rpcStructure(params rpcParams) (any, error) {
+	p, err := findProject(params)
+	if err != nil {
+		return nil, err
+	}
+	structure, err := project.LoadStructure(p.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return structure, err
+}
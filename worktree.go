@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"sapelkin.av/asap_project_manager/project"
+)
+
+func runWorktree(args []string) error {
+	usage := errors.New("usage: asap-pm worktree add <project> <branch> [--path <path>]\n       asap-pm worktree list <project>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("worktree add", flag.ExitOnError)
+		path := fs.String("path", "", "where to check out the branch (default: <project path>-<branch>)")
+		rest, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(rest) != 2 {
+			return usage
+		}
+		return addWorktree(rest[0], rest[1], *path)
+
+	case "list":
+		if len(args) != 2 {
+			return usage
+		}
+		return listWorktrees(args[1])
+	}
+	return usage
+}
+
+func addWorktree(name, branch, path string) error {
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	idx, ok := config.Find(name)
+	if !ok {
+		return fmt.Errorf("project %q not found", name)
+	}
+	parent := config.Projects[idx]
+	if parent.Parent != "" {
+		return fmt.Errorf("%q is itself a worktree of %q", name, parent.Parent)
+	}
+
+	if _, ok := config.Find(parent.Name + "@" + branch); ok {
+		return fmt.Errorf("project %q already exists", parent.Name+"@"+branch)
+	}
+
+	if path == "" {
+		path = project.WorktreePath(parent.Path, branch)
+	} else {
+		path = resolvePath(path)
+	}
+	if err := project.AddWorktree(parent.Path, path, branch); err != nil {
+		return err
+	}
+	fmt.Printf("Checked out %s at %s\n", branch, path)
+
+	return addProject(project.Project{
+		Name:          parent.Name + "@" + branch,
+		Path:          path,
+		Language:      parent.Language,
+		Remote:        parent.Remote,
+		DefaultBranch: parent.DefaultBranch,
+		Parent:        parent.Name,
+		Branch:        branch,
+	})
+}
+
+func listWorktrees(name string) error {
+	config, err := project.LoadConfig()
+	if err != nil {
+		return err
+	}
+	idx, ok := config.Find(name)
+	if !ok {
+		return fmt.Errorf("project %q not found", name)
+	}
+
+	worktrees, err := project.Worktrees(config.Projects[idx].Path)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, wt := range worktrees {
+		registered := ""
+		for _, p := range config.Projects {
+			if p.Path == wt.Path {
+				registered = p.Name
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", wt.Branch, wt.Path, registered)
+	}
+	return w.Flush()
+}
+
+// worktreePicker lists the checkouts of a project in the manage view so
+// one of them can be opened.
+type worktreePicker struct {
+	item      projectItem
+	worktrees []project.Worktree
+	cursor    int
+}
+
+func (m manageProjectsModel) openWorktreePicker(item projectItem) (tea.Model, tea.Cmd) {
+	worktrees, err := project.Worktrees(item.project.Path)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	m.worktrees = &worktreePicker{item: item, worktrees: worktrees}
+	return m, nil
+}
+
+func (m manageProjectsModel) updateWorktreePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	picker := *m.worktrees
+	m.worktrees = &picker
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "w":
+		m.worktrees = nil
+	case "up", "k":
+		picker.cursor = (picker.cursor - 1 + len(picker.worktrees)) % len(picker.worktrees)
+	case "down", "j":
+		picker.cursor = (picker.cursor + 1) % len(picker.worktrees)
+	case "enter":
+		m.worktrees = nil
+		wt := picker.worktrees[picker.cursor]
+		p := picker.item.project
+		p.Path, p.Branch = wt.Path, wt.Branch
+
+		cmd, err := launchCmd(p, "")
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		return m, tea.Batch(
+			hookCmd(project.HookOpen, picker.item.profile, p),
+			tea.ExecProcess(cmd, func(err error) tea.Msg {
+				return launchFinishedMsg{err}
+			}),
+		)
+	}
+	return m, nil
+}
+
+func (p worktreePicker) View() string {
+	s := fmt.Sprintf("Worktrees of '%s':\n", p.item.project.Name)
+	for i, wt := range p.worktrees {
+		cursor := " "
+		if i == p.cursor {
+			cursor = ">"
+		}
+		branch := wt.Branch
+		if branch == "" {
+			branch = "(detached)"
+		}
+		s += fmt.Sprintf("%s %-20s %s\n", cursor, branch, wt.Path)
+	}
+	return s + "\nEnter to open, Esc to close"
+}